@@ -0,0 +1,131 @@
+package main
+
+import (
+	"debug/elf"
+	"fmt"
+	"io"
+)
+
+// ELF header fields the loader requires to be compatible with the executable
+type abiFingerprint struct {
+	machine    elf.Machine
+	class      elf.Class
+	data       elf.Data
+	osabi      elf.OSABI
+	abiVersion uint8
+	// ABI-relevant e_flags bits, see abiFlagMasks
+	flags uint32
+}
+
+// LIBC_ABI_MAX of glibc: the GNU OSABI versions it supports are those below it
+const libcABIMax = 4
+
+// e_flags bits describing the ABI, as opposed to the ISA level or optional extensions
+var abiFlagMasks = map[elf.Machine]uint32{
+	// EF_ARM_ABI_FLOAT_HARD and EF_ARM_ABI_FLOAT_SOFT
+	elf.EM_ARM: 0x400 | 0x200,
+	// EF_MIPS_ABI2 (n32), EF_MIPS_ABI (o32/o64/eabi) and EF_MIPS_NAN2008
+	elf.EM_MIPS: 0x20 | 0xf000 | 0x400,
+	// EF_RISCV_FLOAT_ABI and EF_RISCV_RVE
+	elf.EM_RISCV: 0x6 | 0x8,
+	// EF_PPC64_ABI (ELFv1/ELFv2)
+	elf.EM_PPC64: 0x3,
+	// EF_LOONGARCH_ABI_MODIFIER_MASK
+	elf.EM_LOONGARCH: 0x7,
+}
+
+func newABIFingerprint(f *elf.File, r io.ReaderAt) (abiFingerprint, error) {
+	abi := abiFingerprint{
+		machine:    f.Machine,
+		class:      f.Class,
+		data:       f.Data,
+		osabi:      f.OSABI,
+		abiVersion: f.ABIVersion,
+	}
+
+	// e_flags is not exposed by debug/elf
+	off := int64(36)
+	if f.Class == elf.ELFCLASS64 {
+		off = 48
+	}
+	var buf [4]byte
+	if _, err := r.ReadAt(buf[:], off); err != nil {
+		return abi, fmt.Errorf("newABIFingerprint e_flags: %w", err)
+	}
+	abi.flags = f.ByteOrder.Uint32(buf[:]) & abiFlagMasks[f.Machine]
+
+	return abi, nil
+}
+
+// why the loader of an executable with this fingerprint would reject lib; empty if it would not.
+// Class, machine and e_flags mismatches are skipped by open_verify in glibc, while the other header
+// fields are checked as part of the ELF identification, whose failure is fatal
+func (abi abiFingerprint) incompatibility(lib abiFingerprint) (reason string, fatal bool) {
+	switch {
+	case lib.machine != abi.machine:
+		return fmt.Sprintf("machine %s, expected %s", lib.machine, abi.machine), false
+	case lib.class != abi.class:
+		return fmt.Sprintf("class %s, expected %s", lib.class, abi.class), false
+	case lib.data != abi.data:
+		return fmt.Sprintf("byte order %s, expected %s", lib.data, abi.data), true
+	case normalizeOSABI(lib.osabi) != normalizeOSABI(abi.osabi):
+		return fmt.Sprintf("OSABI %s, expected %s", lib.osabi, abi.osabi), true
+	// like VALID_ELF_ABIVERSION: GNU extensions such as STB_GNU_UNIQUE bump the ABI version of the GNU OSABI only,
+	// whatever the version of the executable is
+	case lib.abiVersion != 0 && !(lib.osabi == elf.ELFOSABI_LINUX && lib.abiVersion < libcABIMax):
+		return fmt.Sprintf("ABI version %d, not supported for %s", lib.abiVersion, lib.osabi), true
+	case !abiFlagsCompatible(abi.machine, abi.flags, lib.flags):
+		return fmt.Sprintf("%s, expected %s", describeABIFlags(lib.machine, lib.flags), describeABIFlags(abi.machine, abi.flags)), false
+	}
+
+	return "", false
+}
+
+func abiFlagsCompatible(machine elf.Machine, exe, lib uint32) bool {
+	switch machine {
+	case elf.EM_ARM, elf.EM_PPC64:
+		// objects not declaring a float ABI or ELF ABI version are accepted either way
+		return exe == 0 || lib == 0 || exe == lib
+	}
+	return exe == lib
+}
+
+// objects for System V and GNU/Linux are interchangeable
+func normalizeOSABI(osabi elf.OSABI) elf.OSABI {
+	if osabi == elf.ELFOSABI_LINUX {
+		return elf.ELFOSABI_NONE
+	}
+	return osabi
+}
+
+func describeABIFlags(machine elf.Machine, flags uint32) string {
+	switch machine {
+	case elf.EM_ARM:
+		if flags&0x400 != 0 {
+			return "ARM hard-float"
+		}
+		return "ARM soft-float"
+	case elf.EM_MIPS:
+		abi := map[uint32]string{0: "none", 0x1000: "o32", 0x2000: "o64", 0x3000: "eabi32", 0x4000: "eabi64"}[flags&0xf000]
+		if flags&0x20 != 0 {
+			abi = "n32"
+		}
+		ret := "MIPS ABI " + abi
+		if flags&0x400 != 0 {
+			ret += ", NaN2008"
+		}
+		return ret
+	case elf.EM_RISCV:
+		ret := "RISC-V float ABI " + []string{"soft", "single", "double", "quad"}[(flags&0x6)>>1]
+		if flags&0x8 != 0 {
+			ret += ", RVE"
+		}
+		return ret
+	case elf.EM_PPC64:
+		return fmt.Sprintf("PPC64 ELFv%d ABI", flags)
+	case elf.EM_LOONGARCH:
+		return "LoongArch float ABI " + map[uint32]string{1: "soft", 2: "single", 3: "double"}[flags]
+	}
+
+	return fmt.Sprintf("e_flags %#x", flags)
+}
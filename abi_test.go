@@ -0,0 +1,51 @@
+package main
+
+import (
+	"debug/elf"
+	"testing"
+)
+
+func TestABIIncompatibility(t *testing.T) {
+	exe := abiFingerprint{machine: elf.EM_X86_64, class: elf.ELFCLASS64, data: elf.ELFDATA2LSB, osabi: elf.ELFOSABI_NONE}
+	gnuExe := exe
+	gnuExe.osabi = elf.ELFOSABI_LINUX
+	gnuExe.abiVersion = 1
+	armExe := abiFingerprint{machine: elf.EM_ARM, class: elf.ELFCLASS32, data: elf.ELFDATA2LSB, flags: 0x400}
+
+	with := func(abi abiFingerprint, change func(*abiFingerprint)) abiFingerprint {
+		change(&abi)
+		return abi
+	}
+
+	tests := []struct {
+		name       string
+		exe        abiFingerprint
+		lib        abiFingerprint
+		wantReason bool
+		wantFatal  bool
+	}{
+		{name: "identical", exe: exe, lib: exe},
+		{name: "SYSV version 0", exe: gnuExe, lib: exe},
+		{name: "SYSV nonzero version", exe: exe, lib: with(exe, func(a *abiFingerprint) { a.abiVersion = 1 }), wantReason: true, wantFatal: true},
+		{name: "SYSV version of the executable", exe: with(exe, func(a *abiFingerprint) { a.abiVersion = 1 }), lib: with(exe, func(a *abiFingerprint) { a.abiVersion = 1 }), wantReason: true, wantFatal: true},
+		{name: "GNU version 0", exe: exe, lib: with(gnuExe, func(a *abiFingerprint) { a.abiVersion = 0 })},
+		{name: "GNU version above the executable's", exe: gnuExe, lib: with(gnuExe, func(a *abiFingerprint) { a.abiVersion = 3 })},
+		{name: "GNU version for a SYSV executable", exe: exe, lib: with(gnuExe, func(a *abiFingerprint) { a.abiVersion = 2 })},
+		{name: "GNU version unknown to glibc", exe: gnuExe, lib: with(gnuExe, func(a *abiFingerprint) { a.abiVersion = libcABIMax }), wantReason: true, wantFatal: true},
+		{name: "other OSABI", exe: exe, lib: with(exe, func(a *abiFingerprint) { a.osabi = elf.ELFOSABI_FREEBSD }), wantReason: true, wantFatal: true},
+		{name: "byte order", exe: exe, lib: with(exe, func(a *abiFingerprint) { a.data = elf.ELFDATA2MSB }), wantReason: true, wantFatal: true},
+		{name: "machine", exe: exe, lib: with(exe, func(a *abiFingerprint) { a.machine = elf.EM_AARCH64 }), wantReason: true},
+		{name: "class", exe: exe, lib: with(exe, func(a *abiFingerprint) { a.class = elf.ELFCLASS32 }), wantReason: true},
+		{name: "ARM float ABI", exe: armExe, lib: with(armExe, func(a *abiFingerprint) { a.flags = 0x200 }), wantReason: true},
+		{name: "ARM without float ABI", exe: armExe, lib: with(armExe, func(a *abiFingerprint) { a.flags = 0 })},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reason, fatal := tt.exe.incompatibility(tt.lib)
+			if (reason != "") != tt.wantReason || fatal != tt.wantFatal {
+				t.Errorf("incompatibility() = %q, %v; want reason %v, fatal %v", reason, fatal, tt.wantReason, tt.wantFatal)
+			}
+		})
+	}
+}
@@ -0,0 +1,338 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"iter"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// linker namespace configuration applying to the base binary, from the matching section of ld.config.txt
+// based on bionic/linker/linker_config.cpp
+type androidConfig struct {
+	path       multiPath
+	section    string
+	namespaces map[string]*androidNamespace
+}
+
+type androidNamespace struct {
+	name           string
+	isolated       bool
+	searchPaths    []multiPath
+	permittedPaths []multiPath
+	// in lookup order
+	links []*androidLink
+}
+
+type androidLink struct {
+	target     string
+	sharedLibs []string
+	allowAll   bool
+}
+
+const androidDefaultNamespace = "default"
+
+// find the config file and the section for the base binary; nil if there is none,
+// in which case the linker falls back to its built-in default search paths
+func (base *baseInfo) getAndroidConfig() (*androidConfig, error) {
+	root := base.options.root
+	elfPath := base.options.elfPath.getRooted()
+
+	candidates := []string{"/linkerconfig/ld.config.txt"}
+	// binaries in APEXes use their own config
+	if rest, ok := strings.CutPrefix(elfPath, "/apex/"); ok {
+		apex, _, _ := strings.Cut(rest, "/")
+		candidates = slices.Insert(candidates, 0, filepath.Join("/linkerconfig", apex, "ld.config.txt"))
+	}
+
+	// the linker picks the file for ro.vndk.version, which cannot be told from the root
+	if version := base.options.vndkVersion; version != "" {
+		candidates = append(candidates, "/system/etc/ld.config."+version+".txt")
+	}
+	candidates = append(candidates, "/system/etc/ld.config.txt")
+
+	for path := range rootedToMultiPath(slices.Values(candidates), root, true) {
+		cfg, err := parseAndroidConfig(path, elfPath, base)
+		if err != nil {
+			return nil, fmt.Errorf("getAndroidConfig %s: %w", path.getRooted(), err)
+		}
+		return cfg, nil
+	}
+
+	return nil, nil
+}
+
+func parseAndroidConfig(path multiPath, elfPath string, base *baseInfo) (*androidConfig, error) {
+	data, err := os.ReadFile(path.getReal())
+	if err != nil {
+		return nil, err
+	}
+
+	root := base.options.root
+	lib := bionicLib(base.class)
+
+	// properties of each section, in file order
+	props := make(map[string][][2]string)
+	var section string
+	var sectionName string
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line, _, _ := strings.Cut(scanner.Text(), "#")
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+
+		if name, ok := strings.CutPrefix(line, "["); ok {
+			section, _ = strings.CutSuffix(name, "]")
+			continue
+		}
+
+		key, value, appendValue := strings.Cut(line, "+=")
+		if !appendValue {
+			var ok bool
+			key, value, ok = strings.Cut(line, "=")
+			if !ok {
+				return nil, fmt.Errorf("invalid line %q", line)
+			}
+		}
+		key = strings.TrimSpace(key)
+		value = strings.TrimSpace(value)
+
+		// dir.X lines come before any section, first match wins
+		if name, ok := strings.CutPrefix(key, "dir."); ok && section == "" {
+			if sectionName == "" && fileIsUnderDir(elfPath, value, root) {
+				sectionName = name
+			}
+			continue
+		}
+
+		if appendValue {
+			key += "+"
+		}
+		props[section] = append(props[section], [2]string{key, value})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	if sectionName == "" {
+		return nil, nil
+	}
+
+	cfg := &androidConfig{
+		path:       path,
+		section:    sectionName,
+		namespaces: make(map[string]*androidNamespace),
+	}
+	cfg.namespace(androidDefaultNamespace)
+
+	expandLib := func(paths string) iter.Seq[string] {
+		return seqMap(slices.Values(strings.Split(paths, ":")), func(path string) (string, bool) {
+			path = strings.ReplaceAll(path, "${LIB}", lib)
+			return path, path != ""
+		})
+	}
+
+	for _, prop := range props[sectionName] {
+		key, value := prop[0], prop[1]
+		isAppend := strings.HasSuffix(key, "+")
+		key = strings.TrimSuffix(key, "+")
+
+		if key == "additional.namespaces" {
+			for name := range strings.SplitSeq(value, ",") {
+				cfg.namespace(strings.TrimSpace(name))
+			}
+			continue
+		}
+
+		rest, ok := strings.CutPrefix(key, "namespace.")
+		if !ok {
+			continue
+		}
+		name, attr, ok := strings.Cut(rest, ".")
+		if !ok {
+			continue
+		}
+		ns := cfg.namespace(name)
+
+		switch attr {
+		case "isolated":
+			ns.isolated = value == "true"
+		case "search.paths":
+			if !isAppend {
+				ns.searchPaths = nil
+			}
+			ns.searchPaths = append(ns.searchPaths, slices.Collect(rootedToMultiPath(expandLib(value), root, true))...)
+		case "permitted.paths":
+			if !isAppend {
+				ns.permittedPaths = nil
+			}
+			ns.permittedPaths = append(ns.permittedPaths, slices.Collect(rootedToMultiPath(expandLib(value), root, true))...)
+		case "links":
+			for target := range strings.SplitSeq(value, ",") {
+				target = strings.TrimSpace(target)
+				cfg.namespace(target)
+				ns.link(target)
+			}
+		default:
+			linkAttr, ok := strings.CutPrefix(attr, "link.")
+			if !ok {
+				continue
+			}
+			target, linkProp, ok := strings.Cut(linkAttr, ".")
+			if !ok {
+				continue
+			}
+			cfg.namespace(target)
+			link := ns.link(target)
+			switch linkProp {
+			case "shared_libs":
+				link.sharedLibs = append(link.sharedLibs, strings.Split(value, ":")...)
+			case "allow_all_shared_libs":
+				link.allowAll = value == "true"
+			}
+		}
+	}
+
+	// namespaces for APEXes are named after the APEX, with dots replaced by underscores
+	for _, ns := range cfg.namespaces {
+		if len(ns.searchPaths) > 0 {
+			continue
+		}
+		apexDir := filepath.Join("/apex", strings.ReplaceAll(ns.name, "_", "."), lib)
+		ns.searchPaths = slices.Collect(rootedToMultiPath(slices.Values([]string{apexDir}), root, true))
+	}
+
+	return cfg, nil
+}
+
+func (cfg *androidConfig) namespace(name string) *androidNamespace {
+	ns, ok := cfg.namespaces[name]
+	if !ok {
+		ns = &androidNamespace{name: name}
+		cfg.namespaces[name] = ns
+	}
+	return ns
+}
+
+func (ns *androidNamespace) link(target string) *androidLink {
+	for _, link := range ns.links {
+		if link.target == target {
+			return link
+		}
+	}
+	link := &androidLink{target: target}
+	ns.links = append(ns.links, link)
+	return link
+}
+
+func (link *androidLink) allows(soname string) bool {
+	return link.allowAll || slices.Contains(link.sharedLibs, soname)
+}
+
+// candidates for the soname in the namespace itself, then in linked namespaces exporting it,
+// along with the namespace they get loaded in; denied is set if the soname exists in a namespace
+// that is not accessible from this one. searchdirs are the phases of the needing object
+func (cfg *androidConfig) lookup(soname, nsName string, searchdirs []searchPhase, base *baseInfo) (paths []sonamePath, loadNs string, denied string) {
+	ns := cfg.namespace(nsName)
+
+	if paths := ns.find(soname, searchdirs, base); len(paths) > 0 {
+		return paths, ns.name, ""
+	}
+
+	for _, link := range ns.links {
+		if !link.allows(soname) {
+			continue
+		}
+		target := cfg.namespace(link.target)
+		paths := target.find(soname, searchdirs, base)
+		for i := range paths {
+			paths[i].Phase = fmt.Sprintf("namespace %s, linked from %s", target.name, ns.name)
+		}
+		if len(paths) > 0 {
+			return paths, target.name, ""
+		}
+	}
+
+	for _, link := range ns.links {
+		if !link.allows(soname) && len(cfg.namespace(link.target).find(soname, searchdirs, base)) > 0 {
+			return nil, "", fmt.Sprintf("namespace %s does not export it to %s", link.target, ns.name)
+		}
+	}
+
+	for _, name := range slices.Sorted(maps.Keys(cfg.namespaces)) {
+		if name != ns.name && len(cfg.namespace(name).find(soname, searchdirs, base)) > 0 {
+			return nil, "", fmt.Sprintf("only in namespace %s, not linked from %s", name, ns.name)
+		}
+	}
+
+	return nil, "", ""
+}
+
+// like open_library in bionic: LD_LIBRARY_PATH, which only applies to the default namespace,
+// then DT_RUNPATH of the needing object, then the namespace's search paths
+func (ns *androidNamespace) find(soname string, searchdirs []searchPhase, base *baseInfo) []sonamePath {
+	root := base.options.root
+	phaseName := "namespace " + ns.name
+
+	if strings.Contains(soname, "/") {
+		return slices.Collect(seqMap(slashSoname(soname, root), func(mp multiPath) (sonamePath, bool) {
+			return newSonamePath(mp, phaseName), !ns.isolated || ns.isAccessible(mp)
+		}))
+	}
+
+	var phases []searchPhase
+	for _, phase := range searchdirs {
+		switch {
+		case phase.kind == phaseLdLibraryPath && ns.name == androidDefaultNamespace:
+			phases = append(phases, phase)
+		case phase.kind == phaseRunpath:
+			// isolated namespaces refuse libraries outside their paths
+			if ns.isolated {
+				phase.dirs = slices.DeleteFunc(slices.Clone(phase.dirs), func(dir multiPath) bool {
+					return !ns.isAccessibleDir(dir.getRooted())
+				})
+			}
+			phases = append(phases, phase)
+		}
+	}
+	phases = append(phases, searchPhase{name: phaseName, dirs: ns.searchPaths})
+
+	return slices.Collect(getSonamePaths(soname, root, phases, nil))
+}
+
+// isolated namespaces may only load libraries from their search and permitted paths
+func (ns *androidNamespace) isAccessible(path multiPath) bool {
+	return ns.isAccessibleDir(filepath.Dir(path.getRooted()))
+}
+
+func (ns *androidNamespace) isAccessibleDir(dir string) bool {
+	for allowed := range concatSeq(slices.Values(ns.searchPaths), slices.Values(ns.permittedPaths)) {
+		allowedDir := allowed.getRooted()
+		if dir == allowedDir || strings.HasPrefix(dir, allowedDir+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// dir is resolved within the root where possible, as the linker resolves it with realpath
+func fileIsUnderDir(path, dir, root string) bool {
+	mp := multiPath{
+		rootPath:  dir,
+		root:      root,
+		mustExist: true,
+	}
+	if mp.fill() == nil {
+		dir = mp.getRooted()
+	}
+	dir = strings.TrimSuffix(dir, "/")
+	return strings.HasPrefix(path, dir+"/")
+}
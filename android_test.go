@@ -0,0 +1,218 @@
+package main
+
+import (
+	"debug/elf"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+const testAndroidConfig = `# comment
+dir.system = /system/bin/
+dir.vendor = /vendor/bin
+dir.other = /system/bin
+
+[system]
+additional.namespaces = sphal,com_android_art
+
+namespace.default.isolated = true
+namespace.default.search.paths = /system/${LIB}
+namespace.default.search.paths += /odm/${LIB}
+namespace.default.permitted.paths = /data/app
+namespace.default.links = sphal,com_android_art
+namespace.default.link.sphal.shared_libs = libvndk.so:libhal.so
+namespace.default.link.com_android_art.allow_all_shared_libs = true
+
+namespace.sphal.search.paths = /vendor/${LIB}
+
+[vendor]
+namespace.default.search.paths = /vendor/${LIB}
+`
+
+// root with the given files created empty, and all their directories
+func testRoot(t *testing.T, files ...string) string {
+	contents := make(map[string]string, len(files))
+	for _, file := range files {
+		contents[file] = ""
+	}
+	return testRootContents(t, contents)
+}
+
+// root with the given files, by rooted path, and all their directories
+func testRootContents(t *testing.T, files map[string]string) string {
+	root := t.TempDir()
+	for file, data := range files {
+		path := filepath.Join(root, file)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return root
+}
+
+func testAndroidBase(root string) *baseInfo {
+	return &baseInfo{
+		options: &parseOptions{root: root},
+		class:   elf.ELFCLASS64,
+	}
+}
+
+func parseTestAndroidConfig(t *testing.T, root, data, elfPath string) *androidConfig {
+	path := filepath.Join(root, "ld.config.txt")
+	if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := parseAndroidConfig(multiPath{realPath: path, root: root}, elfPath, testAndroidBase(root))
+	if err != nil {
+		t.Fatalf("parseAndroidConfig: %v", err)
+	}
+	return cfg
+}
+
+func rootedDirs(dirs []multiPath) []string {
+	return multiPathToRooted(slices.Values(dirs))
+}
+
+func TestParseAndroidConfig(t *testing.T) {
+	root := testRoot(t,
+		"/system/lib64/.keep", "/odm/lib64/.keep", "/vendor/lib64/.keep",
+		"/data/app/.keep", "/apex/com.android.art/lib64/.keep",
+	)
+
+	tests := []struct {
+		name    string
+		elfPath string
+		section string
+	}{
+		{name: "first matching dir wins", elfPath: "/system/bin/app", section: "system"},
+		{name: "dir without trailing slash", elfPath: "/vendor/bin/hal", section: "vendor"},
+		{name: "no matching dir", elfPath: "/product/bin/app", section: ""},
+		{name: "prefix of a directory name does not match", elfPath: "/system/binx/app", section: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := parseTestAndroidConfig(t, root, testAndroidConfig, tt.elfPath)
+			if tt.section == "" {
+				if cfg != nil {
+					t.Fatalf("got section %q, want none", cfg.section)
+				}
+				return
+			}
+			if cfg == nil || cfg.section != tt.section {
+				t.Fatalf("got %+v, want section %q", cfg, tt.section)
+			}
+		})
+	}
+
+	cfg := parseTestAndroidConfig(t, root, testAndroidConfig, "/system/bin/app")
+
+	def := cfg.namespace(androidDefaultNamespace)
+	if !def.isolated {
+		t.Errorf("default namespace not isolated")
+	}
+	if got, want := rootedDirs(def.searchPaths), []string{"/system/lib64", "/odm/lib64"}; !slices.Equal(got, want) {
+		t.Errorf("default search paths: got %v, want %v", got, want)
+	}
+	if got, want := rootedDirs(def.permittedPaths), []string{"/data/app"}; !slices.Equal(got, want) {
+		t.Errorf("default permitted paths: got %v, want %v", got, want)
+	}
+
+	if len(def.links) != 2 || def.links[0].target != "sphal" || def.links[1].target != "com_android_art" {
+		t.Fatalf("default links: got %+v", def.links)
+	}
+	sphal, art := def.links[0], def.links[1]
+	if !sphal.allows("libhal.so") || sphal.allows("libc.so") {
+		t.Errorf("sphal link: got %+v", sphal)
+	}
+	if !art.allows("libanything.so") {
+		t.Errorf("art link does not allow all libraries")
+	}
+
+	if got, want := rootedDirs(cfg.namespace("sphal").searchPaths), []string{"/vendor/lib64"}; !slices.Equal(got, want) {
+		t.Errorf("sphal search paths: got %v, want %v", got, want)
+	}
+	// APEX namespaces without search paths default to their APEX
+	if got, want := rootedDirs(cfg.namespace("com_android_art").searchPaths), []string{"/apex/com.android.art/lib64"}; !slices.Equal(got, want) {
+		t.Errorf("art search paths: got %v, want %v", got, want)
+	}
+}
+
+func TestParseAndroidConfigInvalid(t *testing.T) {
+	root := t.TempDir()
+	path := filepath.Join(root, "ld.config.txt")
+	if err := os.WriteFile(path, []byte("dir.system = /system/bin\n[system]\nnot a property\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := parseAndroidConfig(multiPath{realPath: path, root: root}, "/system/bin/app", testAndroidBase(root)); err == nil {
+		t.Errorf("parseAndroidConfig succeeded, want error")
+	}
+}
+
+func TestAndroidConfigLookup(t *testing.T) {
+	root := testRoot(t,
+		"/system/lib64/libc.so", "/vendor/lib64/libhal.so", "/vendor/lib64/libsecret.so",
+		"/apex/com.android.art/lib64/libart.so", "/opt/runpath/libc.so", "/data/app/lib/libplugin.so",
+		"/odm/lib64/.keep", "/data/app/.keep",
+	)
+	cfg := parseTestAndroidConfig(t, root, testAndroidConfig, "/system/bin/app")
+	base := testAndroidBase(root)
+
+	runpath := searchPhase{
+		kind: phaseRunpath,
+		name: "runpath of /system/bin/app",
+		dirs: slices.Collect(rootedToMultiPath(slices.Values([]string{"/opt/runpath", "/data/app/lib"}), root, true)),
+	}
+
+	tests := []struct {
+		name       string
+		soname     string
+		searchdirs []searchPhase
+		wantPath   string
+		wantNs     string
+		wantDenied bool
+	}{
+		{name: "own search path", soname: "libc.so", wantPath: "/system/lib64/libc.so", wantNs: "default"},
+		{name: "exported by link", soname: "libhal.so", wantPath: "/vendor/lib64/libhal.so", wantNs: "sphal"},
+		{name: "allow all link", soname: "libart.so", wantPath: "/apex/com.android.art/lib64/libart.so", wantNs: "com_android_art"},
+		{name: "not exported by link", soname: "libsecret.so", wantDenied: true},
+		{name: "nowhere", soname: "libnone.so"},
+		{
+			name:       "runpath within permitted paths",
+			soname:     "libplugin.so",
+			searchdirs: []searchPhase{runpath},
+			wantPath:   "/data/app/lib/libplugin.so",
+			wantNs:     "default",
+		},
+		{
+			// the inaccessible runpath entry is skipped by the isolated namespace
+			name:       "runpath outside an isolated namespace",
+			soname:     "libc.so",
+			searchdirs: []searchPhase{runpath},
+			wantPath:   "/system/lib64/libc.so",
+			wantNs:     "default",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			paths, ns, denied := cfg.lookup(tt.soname, androidDefaultNamespace, tt.searchdirs, base)
+			if (denied != "") != tt.wantDenied {
+				t.Errorf("denied: got %q, want %v", denied, tt.wantDenied)
+			}
+			if tt.wantPath == "" {
+				if len(paths) > 0 {
+					t.Errorf("got %v, want no candidates", paths)
+				}
+				return
+			}
+			if len(paths) == 0 || paths[0].Path.getRooted() != tt.wantPath || ns != tt.wantNs {
+				t.Errorf("got %v in %q, want %s in %q", paths, ns, tt.wantPath, tt.wantNs)
+			}
+		})
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"debug/elf"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"path/filepath"
 	"slices"
 )
@@ -82,45 +83,270 @@ type parseOptions struct {
 	elfPath       multiPath
 	root          string
 	ldLibraryPath string
+	preload       string
+	secure        string
+	hwcaps        string
+	platform      string
+	loader        string
+	vdso          string
+	vndkVersion   string
 	getFunc       bool
 	getObject     bool
 	getOther      bool
+	getIfunc      bool
+	getTLS        bool
+	getUnique     bool
+	getProtected  bool
 	full          bool
+	allCandidates bool
+	recursive     bool
 	getWeak       bool
 	std           bool
 	android       bool
+
+	// name[@caller][:global] of objects to dlopen after startup
+	dlopen stringsFlag
 }
 
 type sonameWithSearchdirs struct {
 	soname     string
-	searchdirs []multiPath
+	searchdirs []searchPhase
+	// rooted directory of the object needing the soname, for $ORIGIN
+	origin string
+	// DT_RPATH of the object needing the soname and the objects that loaded it, down to the executable
+	rpaths []rpathEntry
+	// Android linker namespace of the object needing the soname
+	namespace string
+	// object needing the soname
+	owner multiPath
+	// "LD_PRELOAD" or "/etc/ld.so.preload" for preloaded objects, empty for needed ones
+	preload string
+}
+
+// DT_RPATH of an object in the loading chain
+type rpathEntry struct {
+	owner multiPath
+	dirs  []multiPath
+}
+
+// search path related dynamic section entries of an object
+type dynInfo struct {
+	runpath []multiPath
+	// only set if there is no DT_RUNPATH
+	rpath      []multiPath
+	hasRunpath bool
+	flags      elf.DynFlag
+	flags1     elf.DynFlag1
+	// DF_1_NODEFLIB, if the loader supports it
+	nodeflib bool
+	// DT_SYMBOLIC or DF_SYMBOLIC, if the loader supports it: the object's own definitions are looked up first
+	symbolic bool
+}
+
+// names of the set DT_FLAGS and DT_FLAGS_1 bits
+func (info *dynInfo) flagNames() []string {
+	var ret []string
+	for bit := range 32 {
+		if flag := elf.DynFlag(1 << bit); info.flags&flag != 0 {
+			ret = append(ret, flag.String())
+		}
+	}
+	for bit := range 32 {
+		if flag := elf.DynFlag1(1 << bit); info.flags1&flag != 0 {
+			ret = append(ret, flag.String())
+		}
+	}
+	return ret
+}
+
+// shared library opened while resolving sonames
+type libInfo struct {
+	dynInfo
+	syms    []symbol
+	sonames []string
+	// DT_SONAME, empty if there is none
+	soname string
+	// undefined symbols, filtered like the references of the executable
+	refs []symbol
+	// DT_FILTER and DT_AUXILIARY entries
+	filtees []filtee
+}
+
+// search directories only depending on the base binary and the options, filled on first use
+type searchdirCache struct {
+	filled        bool
+	ldLibraryPath []multiPath
+	ldCache       *ldCache
+	hints         *elfHints
+	libmap        *libmap
+	ldSoConf      []multiPath
+	loaderDir     []multiPath
+	defaultDirs   []multiPath
+}
+
+// single step of soname lookup, either a list of directories or an ld.so.cache lookup
+type searchPhase struct {
+	// e.g. "rpath of /usr/bin/foo", "LD_LIBRARY_PATH" or "default"
+	name  string
+	dirs  []multiPath
+	cache *ldCache
+	// step of the loader's search order the phase was built for
+	kind phaseKind
 }
 
 type baseInfo struct {
-	syms    []string
+	syms    []symbol
 	sonames []string
-	runpath []multiPath
+	dynInfo
+	// LD_PRELOAD and /etc/ld.so.preload entries
+	preloads []string
+	// number of LD_PRELOAD entries at the start of preloads, searched for only in the default directories in secure mode
+	envPreloads int
+	// why the binary runs in secure-execution mode, empty if it does not
+	secure string
+	// preloads without a loadable candidate
+	ignoredPreloads []ignoredPreload
+	// DT_AUDIT and DT_DEPAUDIT entries
+	audit []string
+	// candidates the loader would refuse to load, by soname
+	rejected map[string][]rejection
+	// rooted path to the names of the DT_FLAGS and DT_FLAGS_1 bits set in each loaded object
+	objectFlags map[string][]string
 
 	symnameToSonames map[string][]string
-	sonamePaths      map[string][]multiPath
+	sonamePaths      map[string][]sonamePath
 	unneededSonames  []string
 	interpPath       string
+	loader           *loaderProfile
+	hwcaps           []string
+	// values for $LIB and $PLATFORM
+	dstLib   string
+	platform string
+	// references whose name is defined, but never with the required version
+	versionMismatches set[string]
+	// definition each reference binds to
+	symbolDefs    map[string]symbol
+	androidConfig *androidConfig
+	// soname to the reason it cannot be loaded from its namespace
+	deniedSonames map[string]string
+	// definitions exported by the executable itself
+	exported []symbol
+	// global lookup scope, in order, starting with the executable
+	scope []providedSyms
+	// objects in the process by soname, for dlopen
+	loaded map[string]*loadedObject
+	// DT_NEEDED names remapped by libmap.conf
+	libmapped map[string]string
+	// candidates after the one loaded for each soname
+	shadowedPaths map[string][]sonamePath
+	// sonames, and the interpreter by path, without a loadable candidate
+	missingSonames map[string]missingSoname
+	// loaded objects whose DT_SONAME differs from the name they were searched for by
+	sonameMismatches map[string]sonamePath
+	// with -recursive, lookups of the references of each loaded object, by soname
+	libraryRefs map[string]refResolution
+
+	searchdirCache
 
 	options *parseOptions
+	abi     abiFingerprint
 	machine elf.Machine
 	class   elf.Class
+	data    elf.Data
 }
 
 type LddResults struct {
+	// loader profile used for resolution
+	Loader string
+
 	// for correct order
 	Syms    []string
 	Sonames []string
 
+	// providers of each symbol, in lookup scope order
 	SymnameToSonames map[string][]string
-	SonamePaths      map[string][]multiPath
+	SymbolBindings   map[string]symbolBinding
+	SonamePaths      map[string][]sonamePath
 
 	UnneededSonames []string
 	UndefinedSyms   []string
+	// defined, but not with the required version
+	VersionNotFoundSyms []string
+	// weak references without a definition, which the loader sets to NULL instead of failing
+	WeakUnresolvedSyms []string
+	// audit libraries of the executable, from DT_AUDIT and DT_DEPAUDIT
+	Audit []string
+	// DT_FLAGS and DT_FLAGS_1 bits set in each loaded object, by path
+	ObjectFlags map[string][]string
+	// candidates skipped as incompatible with the executable, by soname
+	Rejected map[string][]rejection
+	// why the binary runs in secure-execution mode (e.g. "setuid"), empty if it does not
+	Secure string
+	// bindings taken over by preloaded objects
+	Interposed map[string]interposition
+	// preloads the loader cannot load, and goes on without
+	IgnoredPreloads []ignoredPreload
+	// sonames only present in Android linker namespaces inaccessible to the needing object
+	DeniedSonames map[string]string
+	// objects opened with -dlopen, in order
+	Plugins []pluginResult
+	// DT_NEEDED names to what libmap.conf remaps them to
+	Libmap map[string]string
+	// candidates found after the one loaded for each soname, which the loader never looks at
+	ShadowedPaths map[string][]sonamePath
+	// sonames without a loadable candidate, and the interpreter by path if it does not exist in the root
+	MissingSonames map[string]missingSoname
+	// loaded objects whose DT_SONAME differs from the name they were needed by, by that name
+	SonameMismatches map[string]sonamePath
+	// with -recursive, lookups of the references of each loaded object, by soname
+	LibraryRefs map[string]refResolution
+}
+
+// definition a symbol reference binds to
+type symbolBinding struct {
+	// first provider in lookup scope order
+	Provider string
+	// later providers, whose definitions are never used
+	Shadowed []string
+	// "ifunc", "tls", "unique" or "protected" for special definitions
+	Attributes []string `json:",omitempty"`
+	// the reference is weak
+	Weak bool `json:",omitempty"`
+}
+
+// candidate path for a soname, along with why the loader would skip it
+type rejection struct {
+	Path   multiPath
+	Reason string
+	// the loader fails to load the soname instead of trying the next candidate
+	Fatal bool `json:",omitempty"`
+}
+
+// path a soname was resolved to
+type sonamePath struct {
+	Path multiPath
+	// search phase the path was found in, e.g. "rpath of /usr/bin/foo" or "ld.so.cache"
+	Phase string
+	// glibc-hwcaps subdirectory the path was found in
+	Hwcaps string `json:",omitempty"`
+	// DT_SONAME of the file, once loaded
+	Soname string `json:",omitempty"`
+}
+
+func newSonamePath(path multiPath, phase string) sonamePath {
+	return sonamePath{
+		Path:   path,
+		Phase:  phase,
+		Hwcaps: hwcapsOf(path.getRooted()),
+	}
+}
+
+func (sp *sonamePath) String() string {
+	path := sp.Path.getRooted()
+	if sp.Hwcaps == "" {
+		return fmt.Sprintf("%s (%s)", path, sp.Phase)
+	}
+	return fmt.Sprintf("%s (%s, hwcaps %s)", path, sp.Phase, sp.Hwcaps)
 }
 
 type multiPath struct {
@@ -169,6 +395,6 @@ func (mp *multiPath) getRooted() string {
 	return mp.rootPath
 }
 
-func (mp *multiPath) MarshalJSON() ([]byte, error) {
+func (mp multiPath) MarshalJSON() ([]byte, error) {
 	return json.Marshal(mp.getRooted())
 }
@@ -0,0 +1,309 @@
+package main
+
+import (
+	"debug/elf"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+)
+
+// flag that can be given more than once
+type stringsFlag []string
+
+func (s *stringsFlag) String() string {
+	return strings.Join(*s, ", ")
+}
+
+func (s *stringsFlag) Set(value string) error {
+	*s = append(*s, value)
+	return nil
+}
+
+// object in the process, along with what is needed to resolve its dependencies
+type loadedObject struct {
+	soname string
+	path   multiPath
+	lib    *libInfo
+	// DT_RPATH chain starting at the object itself
+	rpaths []rpathEntry
+}
+
+// outcome of a simulated dlopen
+type pluginResult struct {
+	Name string
+	// soname of the calling object, or the rooted path of the executable
+	Caller string
+	Path   *sonamePath `json:",omitempty"`
+	// opened with RTLD_GLOBAL, or marked DF_1_GLOBAL
+	Global bool
+	// objects loaded for the plugin's closure that were not in the process yet
+	Sonames []string
+	// dependencies that were not found, making dlopen fail
+	MissingSonames []string
+	refResolution
+	// why dlopen fails before loading anything
+	Error string `json:",omitempty"`
+}
+
+// simulate the -dlopen options in order, each one seeing the RTLD_GLOBAL objects of the previous ones
+func (base *baseInfo) openPlugins() ([]pluginResult, error) {
+	var ret []pluginResult
+	for _, spec := range base.options.dlopen {
+		target, mode, _ := strings.Cut(spec, ":")
+		if mode != "" && mode != "global" && mode != "local" {
+			return nil, fmt.Errorf("openPlugins %s: unknown mode %q", spec, mode)
+		}
+		name, caller, _ := strings.Cut(target, "@")
+		res, err := base.dlopen(name, caller, mode == "global")
+		if err != nil {
+			return nil, fmt.Errorf("openPlugins %s: %w", spec, err)
+		}
+		ret = append(ret, res)
+	}
+
+	return ret, nil
+}
+
+// based on dl_open_worker in glibc's dl-open.c: the plugin is searched for with the rules of the caller,
+// and its references are looked up in the global scope, then in its own dependency closure
+func (base *baseInfo) dlopen(name, callerName string, global bool) (pluginResult, error) {
+	res := pluginResult{Name: name, Caller: callerName}
+
+	caller := &loadedObject{
+		path:   base.options.elfPath,
+		lib:    &libInfo{dynInfo: base.dynInfo},
+		rpaths: []rpathEntry{{owner: base.options.elfPath, dirs: base.rpath}},
+	}
+	if callerName == "" {
+		res.Caller = base.options.elfPath.getRooted()
+	} else {
+		var ok bool
+		if caller, ok = base.loaded[callerName]; !ok {
+			res.Error = "caller is not loaded"
+			return res, nil
+		}
+	}
+
+	// objects already in the process are reused, even if they were opened with RTLD_LOCAL
+	plugin, ok := base.loaded[name]
+	if !ok {
+		searchdirs := getSearchdirs(caller.path, caller.lib.dynInfo, caller.rpaths, base)
+		sp, lib, err := base.loadObject(name, searchdirs, caller.path)
+		if err != nil {
+			return res, fmt.Errorf("dlopen: %w", err)
+		}
+		if sp == nil {
+			res.Error = "not found"
+			return res, nil
+		}
+		res.Path = sp
+		if lib.flags1&elf.DF_1_NOOPEN != 0 {
+			res.Error = "DF_1_NOOPEN is set"
+			return res, nil
+		}
+		plugin = base.addLoaded(name, *sp, lib, caller.rpaths)
+		res.Sonames = append(res.Sonames, name)
+	} else {
+		// without -full, only sonames the executable needs are in sonamePaths
+		sp := sonamePath{Path: plugin.path, Phase: "already loaded", Soname: plugin.lib.soname}
+		if sps := base.sonamePaths[name]; len(sps) > 0 {
+			sp = sps[0]
+		}
+		res.Path = &sp
+	}
+
+	// the plugin's own lookup scope, breadth-first like for the executable
+	local := []*loadedObject{plugin}
+	seenSonames := newSet[string]()
+	seenSonames.add(name)
+	for i := 0; i < len(local); i++ {
+		parent := local[i]
+		for _, soname := range parent.lib.sonames {
+			if seenSonames.contains(soname) {
+				continue
+			}
+			seenSonames.add(soname)
+
+			dep, ok := base.loaded[soname]
+			if !ok {
+				searchdirs := getSearchdirs(parent.path, parent.lib.dynInfo, parent.rpaths, base)
+				sp, lib, err := base.loadObject(soname, searchdirs, parent.path)
+				if err != nil {
+					return res, fmt.Errorf("dlopen: %w", err)
+				}
+				if sp == nil {
+					res.MissingSonames = append(res.MissingSonames, soname)
+					continue
+				}
+				dep = base.addLoaded(soname, *sp, lib, parent.rpaths)
+				res.Sonames = append(res.Sonames, soname)
+			}
+			local = append(local, dep)
+		}
+	}
+
+	scope := slices.Clone(base.scope)
+	for _, obj := range local {
+		scope = appendScope(scope, providedSyms{soname: obj.soname, syms: obj.lib.syms})
+	}
+	res.refResolution = base.bindRefs(plugin.lib.refs, plugin.lookupScope(scope))
+	// objects loaded for the plugin resolve their references in the same scope
+	if base.options.recursive {
+		for _, obj := range local[1:] {
+			if slices.Contains(res.Sonames, obj.soname) {
+				base.libraryRefs[obj.soname] = base.bindRefs(obj.lib.refs, obj.lookupScope(scope))
+			}
+		}
+	}
+
+	if global || plugin.lib.flags1&elf.DF_1_GLOBAL != 0 {
+		res.Global = true
+		for _, obj := range local {
+			base.scope = appendScope(base.scope, providedSyms{soname: obj.soname, syms: obj.lib.syms})
+		}
+	}
+
+	return res, nil
+}
+
+// record a newly loaded object; parentRpaths is the DT_RPATH chain of the object loading it
+func (base *baseInfo) addLoaded(soname string, sp sonamePath, lib *libInfo, parentRpaths []rpathEntry) *loadedObject {
+	obj := &loadedObject{
+		soname: soname,
+		path:   sp.Path,
+		lib:    lib,
+		rpaths: append([]rpathEntry{{owner: sp.Path, dirs: lib.rpath}}, parentRpaths...),
+	}
+	base.loaded[soname] = obj
+	base.sonames = append(base.sonames, soname)
+	base.sonamePaths[soname] = append(base.sonamePaths[soname], sp)
+	base.recordFlags(sp.Path, &lib.dynInfo)
+	return obj
+}
+
+// objects already in the scope are not added again
+func appendScope(scope []providedSyms, provider providedSyms) []providedSyms {
+	if slices.ContainsFunc(scope, func(p providedSyms) bool { return p.soname == provider.soname }) {
+		return scope
+	}
+	return append(scope, provider)
+}
+
+// outcome of looking up a set of references
+type refResolution struct {
+	SymbolBindings      map[string]symbolBinding
+	UndefinedSyms       []string
+	VersionNotFoundSyms []string
+	// weak references without a definition, which the loader sets to NULL
+	WeakUnresolvedSyms []string
+}
+
+// look up each reference in the scope, in order
+func (base *baseInfo) bindRefs(refs []symbol, scope []providedSyms) refResolution {
+	type scopedDef struct {
+		soname string
+		def    symbol
+	}
+	defs := make(map[string][]scopedDef)
+	for _, provider := range scope {
+		for _, def := range provider.syms {
+			defs[def.name] = append(defs[def.name], scopedDef{soname: provider.soname, def: def})
+		}
+	}
+
+	ret := refResolution{SymbolBindings: make(map[string]symbolBinding)}
+	for _, ref := range refs {
+		key := ref.String()
+		var matches []scopedDef
+		mismatch := false
+		for _, sd := range defs[ref.name] {
+			if !base.symMatches(ref, sd.def) {
+				mismatch = true
+				continue
+			}
+			if !slices.ContainsFunc(matches, func(m scopedDef) bool { return m.soname == sd.soname }) {
+				matches = append(matches, sd)
+			}
+		}
+
+		switch {
+		case len(matches) > 0:
+			if !base.options.tracksDef(matches[0].def) {
+				continue
+			}
+			shadowed := make([]string, 0, len(matches)-1)
+			for _, m := range matches[1:] {
+				shadowed = append(shadowed, m.soname)
+			}
+			ret.SymbolBindings[key] = symbolBinding{
+				Provider:   matches[0].soname,
+				Shadowed:   shadowed,
+				Attributes: matches[0].def.names(),
+				Weak:       ref.weak,
+			}
+		case ref.weak:
+			ret.WeakUnresolvedSyms = append(ret.WeakUnresolvedSyms, key)
+		case mismatch:
+			ret.VersionNotFoundSyms = append(ret.VersionNotFoundSyms, key)
+		default:
+			ret.UndefinedSyms = append(ret.UndefinedSyms, key)
+		}
+	}
+
+	return ret
+}
+
+func (res *pluginResult) noNil() {
+	for _, slicePtr := range []*[]string{&res.Sonames, &res.MissingSonames} {
+		if *slicePtr == nil {
+			*slicePtr = make([]string, 0)
+		}
+	}
+	res.refResolution.noNil()
+}
+
+func (res *refResolution) noNil() {
+	for _, slicePtr := range []*[]string{&res.UndefinedSyms, &res.VersionNotFoundSyms, &res.WeakUnresolvedSyms} {
+		if *slicePtr == nil {
+			*slicePtr = make([]string, 0)
+		}
+	}
+	if res.SymbolBindings == nil {
+		res.SymbolBindings = make(map[string]symbolBinding)
+	}
+}
+
+func (res *pluginResult) print() {
+	mode := "RTLD_LOCAL"
+	if res.Global {
+		mode = "RTLD_GLOBAL"
+	}
+	fmt.Println()
+	if res.Error != "" {
+		fmt.Printf("DLOPEN %s from %s: %s\n", res.Name, res.Caller, res.Error)
+		return
+	}
+	fmt.Printf("DLOPEN %s from %s (%s): %s\n", res.Name, res.Caller, mode, res.Path)
+
+	for _, sym := range slices.Sorted(maps.Keys(res.SymbolBindings)) {
+		binding := res.SymbolBindings[sym]
+		fmt.Printf("  %s: %s\n", sym, binding.Provider)
+	}
+
+	if len(res.Sonames) > 0 {
+		fmt.Printf("  LOADED: %s\n", strings.Join(res.Sonames, ", "))
+	}
+	if len(res.MissingSonames) > 0 {
+		fmt.Printf("  MISSING: %s\n", strings.Join(res.MissingSonames, ", "))
+	}
+	if len(res.UndefinedSyms) > 0 {
+		fmt.Printf("  UNDEFINED: %s\n", strings.Join(res.UndefinedSyms, ", "))
+	}
+	if len(res.VersionNotFoundSyms) > 0 {
+		fmt.Printf("  VERSION NOT FOUND: %s\n", strings.Join(res.VersionNotFoundSyms, ", "))
+	}
+	if len(res.WeakUnresolvedSyms) > 0 {
+		fmt.Printf("  WEAK UNRESOLVED: %s\n", strings.Join(res.WeakUnresolvedSyms, ", "))
+	}
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"fmt"
+	"iter"
+	"strings"
+)
+
+// expand $ORIGIN, $LIB and $PLATFORM (or their ${} forms) as in _dl_dst_substitute in glibc,
+// limited to the tokens the loader supports;
+// origin is the rooted directory of the object the string came from.
+// returns false if a token has no value, or $ORIGIN leads outside the trusted directories in secure mode,
+// in which case the loader drops the string
+func (base *baseInfo) expandDST(s, origin string) (string, bool) {
+	ret, dropped := base.expandDSTReason(s, origin)
+	return ret, dropped == ""
+}
+
+// like expandDST, with why the string is dropped instead of false
+func (base *baseInfo) expandDSTReason(s, origin string) (string, string) {
+	if !strings.Contains(s, "$") {
+		return s, ""
+	}
+
+	var sb strings.Builder
+	usedOrigin := false
+	for {
+		index := strings.IndexByte(s, '$')
+		if index == -1 {
+			sb.WriteString(s)
+			break
+		}
+
+		sb.WriteString(s[:index])
+		s = s[index+1:]
+
+		token, rest, ok := cutDSTToken(s, base.loader.dstTokens)
+		if !ok {
+			// not a known token, kept as-is
+			sb.WriteByte('$')
+			continue
+		}
+
+		var value string
+		switch token {
+		case "ORIGIN":
+			value = origin
+			usedOrigin = true
+		case "LIB":
+			value = base.dstLib
+		case "PLATFORM":
+			value = base.platform
+		}
+		if value == "" {
+			return "", fmt.Sprintf("$%s has no value", token)
+		}
+
+		sb.WriteString(value)
+		s = rest
+	}
+
+	ret := sb.String()
+	if usedOrigin && base.secure != "" && !base.isTrustedPath(ret) {
+		return "", "$ORIGIN leads outside the trusted directories in secure mode"
+	}
+
+	return ret, ""
+}
+
+func (base *baseInfo) expandDSTs(seq iter.Seq[string], origin string) iter.Seq[string] {
+	return seqMap(seq, func(s string) (string, bool) {
+		return base.expandDST(s, origin)
+	})
+}
+
+// s is the string following a '$'
+func cutDSTToken(s string, tokens []string) (token, rest string, ok bool) {
+	for _, token := range tokens {
+		if rest, ok := strings.CutPrefix(s, "{"+token+"}"); ok {
+			return token, rest, true
+		}
+		if rest, ok := strings.CutPrefix(s, token); ok && (rest == "" || !isIdentChar(rest[0])) {
+			return token, rest, true
+		}
+	}
+
+	return "", "", false
+}
+
+func isIdentChar(c byte) bool {
+	return c == '_' || ('0' <= c && c <= '9') || ('a' <= c && c <= 'z') || ('A' <= c && c <= 'Z')
+}
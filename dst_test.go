@@ -0,0 +1,79 @@
+package main
+
+import "testing"
+
+func TestExpandDST(t *testing.T) {
+	trusted := multiPath{realPath: "/lib64", rootPath: "/lib64", root: "/"}
+
+	tests := []struct {
+		name     string
+		loader   *loaderProfile
+		s        string
+		origin   string
+		platform string
+		secure   string
+		want     string
+		wantOK   bool
+	}{
+		{name: "no tokens", loader: profileGlibc, s: "/usr/lib", want: "/usr/lib", wantOK: true},
+		{name: "origin", loader: profileGlibc, s: "$ORIGIN/../lib", origin: "/opt/app/bin", want: "/opt/app/bin/../lib", wantOK: true},
+		{name: "braced origin", loader: profileGlibc, s: "${ORIGIN}/lib", origin: "/opt/app", want: "/opt/app/lib", wantOK: true},
+		{name: "braced origin followed by identifier", loader: profileGlibc, s: "${ORIGIN}X", origin: "/opt/app", want: "/opt/appX", wantOK: true},
+		{name: "longer identifier is not a token", loader: profileGlibc, s: "$ORIGINX/lib", origin: "/opt/app", want: "$ORIGINX/lib", wantOK: true},
+		{name: "unknown token", loader: profileGlibc, s: "/lib/$FOO", want: "/lib/$FOO", wantOK: true},
+		{name: "trailing dollar", loader: profileGlibc, s: "/lib$", want: "/lib$", wantOK: true},
+		{name: "lib", loader: profileGlibc, s: "/usr/$LIB/foo", want: "/usr/lib64/foo", wantOK: true},
+		{name: "platform", loader: profileGlibc, s: "/usr/lib/$PLATFORM", platform: "haswell", want: "/usr/lib/haswell", wantOK: true},
+		{name: "platform without value", loader: profileGlibc, s: "/usr/lib/$PLATFORM", wantOK: false},
+		{name: "token unsupported by the loader", loader: profileMusl, s: "/usr/$LIB", want: "/usr/$LIB", wantOK: true},
+		{name: "secure origin outside trusted directories", loader: profileGlibc, s: "$ORIGIN/lib", origin: "/opt/app", secure: "setuid", wantOK: false},
+		{name: "secure origin within trusted directories", loader: profileGlibc, s: "$ORIGIN/sub", origin: "/lib64", secure: "setuid", want: "/lib64/sub", wantOK: true},
+		{name: "secure origin with untrusting loader", loader: profileMusl, s: "$ORIGIN/sub", origin: "/lib64", secure: "setuid", wantOK: false},
+		{name: "secure without origin", loader: profileGlibc, s: "/opt/$LIB", secure: "setuid", want: "/opt/lib64", wantOK: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			base := &baseInfo{
+				loader:   tt.loader,
+				dstLib:   "lib64",
+				platform: tt.platform,
+				secure:   tt.secure,
+			}
+			base.searchdirCache = searchdirCache{filled: true, defaultDirs: []multiPath{trusted}}
+
+			got, ok := base.expandDST(tt.s, tt.origin)
+			if ok != tt.wantOK || got != tt.want {
+				t.Errorf("expandDST(%q) = %q, %v; want %q, %v", tt.s, got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestCutDSTToken(t *testing.T) {
+	tokens := []string{"ORIGIN", "LIB", "PLATFORM"}
+
+	tests := []struct {
+		s         string
+		wantToken string
+		wantRest  string
+		wantOK    bool
+	}{
+		{s: "ORIGIN", wantToken: "ORIGIN", wantOK: true},
+		{s: "ORIGIN/lib", wantToken: "ORIGIN", wantRest: "/lib", wantOK: true},
+		{s: "{ORIGIN}lib", wantToken: "ORIGIN", wantRest: "lib", wantOK: true},
+		{s: "ORIGINX", wantOK: false},
+		{s: "ORIGIN_1", wantOK: false},
+		{s: "{ORIGIN", wantOK: false},
+		{s: "LIBS", wantOK: false},
+		{s: "LIB.so", wantToken: "LIB", wantRest: ".so", wantOK: true},
+		{s: "origin", wantOK: false},
+	}
+
+	for _, tt := range tests {
+		token, rest, ok := cutDSTToken(tt.s, tokens)
+		if token != tt.wantToken || rest != tt.wantRest || ok != tt.wantOK {
+			t.Errorf("cutDSTToken(%q) = %q, %q, %v; want %q, %q, %v", tt.s, token, rest, ok, tt.wantToken, tt.wantRest, tt.wantOK)
+		}
+	}
+}
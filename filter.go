@@ -0,0 +1,66 @@
+package main
+
+import (
+	"debug/elf"
+	"errors"
+	"fmt"
+)
+
+// object named by DT_FILTER or DT_AUXILIARY, which symbol lookups in the filter are redirected to
+type filtee struct {
+	soname string
+	// DT_AUXILIARY; the filter is still usable if the filtee is missing
+	auxiliary bool
+}
+
+// definitions along with the soname they are attributed to
+type providedSyms struct {
+	soname string
+	syms   []symbol
+}
+
+func readFiltees(f *elf.File) ([]filtee, error) {
+	var ret []filtee
+	for _, tag := range []elf.DynTag{elf.DT_FILTER, elf.DT_AUXILIARY} {
+		sonames, err := dynStrings(f, tag)
+		if err != nil {
+			return nil, err
+		}
+		for _, soname := range sonames {
+			ret = append(ret, filtee{
+				soname:    soname,
+				auxiliary: tag == elf.DT_AUXILIARY,
+			})
+		}
+	}
+
+	return ret, nil
+}
+
+// like f.DynString, which rejects tags it does not know to be string-valued
+func dynStrings(f *elf.File, tag elf.DynTag) ([]string, error) {
+	offsets, err := f.DynValue(tag)
+	if err != nil || len(offsets) == 0 {
+		return nil, err
+	}
+
+	ds := f.SectionByType(elf.SHT_DYNAMIC)
+	if int(ds.Link) >= len(f.Sections) {
+		return nil, errors.New("dynStrings: invalid string table link")
+	}
+	strTab, err := f.Sections[ds.Link].Data()
+	if err != nil {
+		return nil, fmt.Errorf("dynStrings: %w", err)
+	}
+
+	var ret []string
+	for _, off := range offsets {
+		s, ok := cString(strTab, uint32(off))
+		if !ok {
+			return nil, errors.New("dynStrings: invalid string offset")
+		}
+		ret = append(ret, s)
+	}
+
+	return ret, nil
+}
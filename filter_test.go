@@ -0,0 +1,72 @@
+package main
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+// compile src into dir/out with the host C compiler, skipping the test if there is none
+func buildTestELF(t *testing.T, dir, out, src string, args ...string) string {
+	cc, err := exec.LookPath("cc")
+	if err != nil {
+		t.Skip("no C compiler")
+	}
+	srcPath := filepath.Join(dir, out+".c")
+	if err := os.WriteFile(srcPath, []byte(src), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, out)
+	cmd := exec.Command(cc, append([]string{"-o", path, srcPath}, args...)...)
+	if output, err := cmd.CombinedOutput(); err != nil {
+		t.Skipf("cc: %v: %s", err, output)
+	}
+	return path
+}
+
+func TestMissingFiltees(t *testing.T) {
+	dir := t.TempDir()
+	filter := buildTestELF(t, dir, "libfilt.so", "int filt_own(void) { return 1; }\n",
+		"-shared", "-fPIC", "-Wl,-soname,libfilt.so", "-Wl,--filter=libnope-filter.so")
+	buildTestELF(t, dir, "libaux.so", "int aux_own(void) { return 2; }\n",
+		"-shared", "-fPIC", "-Wl,-soname,libaux.so", "-Wl,--auxiliary=libnope-aux.so")
+	app := buildTestELF(t, dir, "app", "int filt_own(void);\nint aux_own(void);\nint main(void) { return filt_own() + aux_own(); }\n",
+		"-L"+dir, "-lfilt", "-laux", "-Wl,-rpath,$ORIGIN")
+
+	options := &parseOptions{
+		elfPath:   multiPath{rootPath: app},
+		root:      "/",
+		loader:    "glibc",
+		secure:    "false",
+		getFunc:   true,
+		getObject: true,
+		full:      true,
+		std:       true,
+	}
+	res, err := lddSym(options)
+	if err != nil {
+		t.Fatalf("lddSym: %v", err)
+	}
+
+	// glibc fails to load the filter, so nothing binds to it
+	missing, ok := res.MissingSonames["libnope-filter.so"]
+	if !ok {
+		t.Fatalf("DT_FILTER filtee not missing: %v", res.MissingSonames)
+	}
+	if !missing.Filtee || missing.Reason == "" || !slices.Equal(missing.NeededBy, []string{filter}) {
+		t.Errorf("DT_FILTER filtee = %+v, want filtee of %s with a reason", missing, filter)
+	}
+	if !slices.Contains(res.UndefinedSyms, "filt_own") {
+		t.Errorf("filt_own not undefined: %v", res.SymbolBindings["filt_own"])
+	}
+
+	// the filter's own definitions are used without a DT_AUXILIARY filtee
+	if _, ok := res.MissingSonames["libnope-aux.so"]; ok {
+		t.Errorf("DT_AUXILIARY filtee reported missing")
+	}
+	if got := res.SymbolBindings["aux_own"].Provider; got != "libaux.so" {
+		t.Errorf("aux_own bound to %q, want libaux.so", got)
+	}
+}
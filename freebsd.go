@@ -0,0 +1,226 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"debug/elf"
+	"encoding/binary"
+	"errors"
+	"iter"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// based on sys/elf-hints.h and libexec/rtld-elf/rtld_paths.h in FreeBSD
+const (
+	elfHintsMagic      = 0x746e6845
+	elfHintsVersion    = 1
+	elfHintsHeaderSize = 128
+)
+
+// search directories from ld-elf.so.hints, which ldconfig writes instead of a soname cache
+type elfHints struct {
+	// rooted path of the hints file
+	path string
+	dirs []multiPath
+}
+
+// soname remappings from libmap.conf, based on libexec/rtld-elf/libmap.c in FreeBSD
+type libmap struct {
+	// mappings outside of any section
+	defaults map[string]string
+	// in reverse file order, as later sections take precedence
+	sections []libmapSection
+}
+
+// [constraint] section of libmap.conf
+type libmapSection struct {
+	// executable or library path, basename, or directory if it ends with a slash
+	constraint string
+	mappings   map[string]string
+}
+
+// 32-bit objects on 64-bit systems use their own hints, libmap and default directories
+func (base *baseInfo) freebsdCompat32() bool {
+	if base.class != elf.ELFCLASS32 {
+		return false
+	}
+	mp := multiPath{
+		rootPath:  "/libexec/ld-elf32.so.1",
+		root:      base.options.root,
+		mustExist: true,
+	}
+	return mp.fill() == nil
+}
+
+func (base *baseInfo) getSearchDirCachedFreeBSD() iter.Seq[multiPath] {
+	paths := []string{"/lib/casper", "/lib", "/usr/lib"}
+	if base.freebsdCompat32() {
+		paths = []string{"/lib32", "/usr/lib32"}
+	}
+	return rootedToMultiPath(slices.Values(paths), base.options.root, true)
+}
+
+// nil if the root has no usable hints file
+func (base *baseInfo) getElfHints() *elfHints {
+	path := "/var/run/ld-elf.so.hints"
+	if base.freebsdCompat32() {
+		path = "/var/run/ld-elf32.so.hints"
+	}
+	mp := multiPath{
+		rootPath:  path,
+		root:      base.options.root,
+		mustExist: true,
+	}
+	if mp.fill() != nil {
+		return nil
+	}
+
+	data, err := os.ReadFile(mp.getReal())
+	if err != nil {
+		return nil
+	}
+	dirs, err := parseElfHints(data)
+	if err != nil {
+		return nil
+	}
+
+	return &elfHints{
+		path: path,
+		dirs: slices.Collect(uniqExistsPath(rootedToMultiPath(slices.Values(dirs), base.options.root, true))),
+	}
+}
+
+func parseElfHints(data []byte) ([]string, error) {
+	if len(data) < elfHintsHeaderSize {
+		return nil, errors.New("parseElfHints: truncated header")
+	}
+
+	// written in the byte order of the system ldconfig ran on
+	var byteOrder binary.ByteOrder
+	for _, bo := range []binary.ByteOrder{binary.LittleEndian, binary.BigEndian} {
+		if bo.Uint32(data) == elfHintsMagic {
+			byteOrder = bo
+			break
+		}
+	}
+	if byteOrder == nil {
+		return nil, errors.New("parseElfHints: unknown magic")
+	}
+	if byteOrder.Uint32(data[4:]) != elfHintsVersion {
+		return nil, errors.New("parseElfHints: unsupported version")
+	}
+
+	strTab := uint64(byteOrder.Uint32(data[8:]))
+	dirList := uint64(byteOrder.Uint32(data[16:]))
+	dirListLen := uint64(byteOrder.Uint32(data[20:]))
+	start := strTab + dirList
+	if start+dirListLen > uint64(len(data)) {
+		return nil, errors.New("parseElfHints: truncated directory list")
+	}
+
+	return strings.FieldsFunc(string(data[start:start+dirListLen]), func(r rune) bool { return r == ':' }), nil
+}
+
+// nil if the root has no libmap.conf
+func (base *baseInfo) getLibmap() *libmap {
+	path := "/etc/libmap.conf"
+	if base.freebsdCompat32() {
+		path = "/etc/libmap32.conf"
+	}
+	mp := multiPath{
+		rootPath:  path,
+		root:      base.options.root,
+		mustExist: true,
+	}
+	if mp.fill() != nil {
+		return nil
+	}
+
+	lm := &libmap{defaults: make(map[string]string)}
+	lm.parseFile(mp, base.options.root, newSet[string]())
+	return lm
+}
+
+// unreadable files are skipped, like rtld does
+func (lm *libmap) parseFile(path multiPath, root string, seen set[string]) {
+	if seen.contains(path.getRooted()) {
+		return
+	}
+	seen.add(path.getRooted())
+
+	data, err := os.ReadFile(path.getReal())
+	if err != nil {
+		return
+	}
+
+	mappings := lm.defaults
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line, _, _ := strings.Cut(scanner.Text(), "#")
+		line = strings.TrimSpace(line)
+		if rest, ok := strings.CutPrefix(line, "["); ok {
+			constraint, _, _ := strings.Cut(rest, "]")
+			constraint = strings.TrimSpace(constraint)
+			lm.sections = slices.Insert(lm.sections, 0, libmapSection{constraint: constraint, mappings: make(map[string]string)})
+			mappings = lm.sections[0].mappings
+			continue
+		}
+
+		fields := strings.Fields(line)
+		if len(fields) < 2 {
+			continue
+		}
+
+		switch fields[0] {
+		case "include":
+			include := multiPath{rootPath: fields[1], root: root, mustExist: true}
+			if include.fill() == nil {
+				lm.parseFile(include, root, seen)
+			}
+			continue
+		case "includedir":
+			dir := multiPath{rootPath: fields[1], root: root, mustExist: true}
+			if dir.fill() != nil {
+				continue
+			}
+			files, _ := filepath.Glob(filepath.Join(dir.getReal(), "*.conf"))
+			for _, file := range files {
+				include := multiPath{realPath: file, root: root, mustExist: true}
+				if include.fill() == nil {
+					lm.parseFile(include, root, seen)
+				}
+			}
+			continue
+		}
+
+		mappings[fields[0]] = fields[1]
+	}
+}
+
+// name to search for instead of soname when needed by the object at the rooted path owner, based on lm_find
+func (lm *libmap) lookup(owner, soname string) (string, bool) {
+	for _, section := range lm.sections {
+		if section.matches(owner) {
+			if mapped, ok := section.mappings[soname]; ok {
+				return mapped, true
+			}
+			break
+		}
+	}
+
+	mapped, ok := lm.defaults[soname]
+	return mapped, ok
+}
+
+func (section libmapSection) matches(path string) bool {
+	switch {
+	case strings.HasSuffix(section.constraint, "/"):
+		return strings.HasPrefix(path, section.constraint)
+	case strings.Contains(section.constraint, "/"):
+		return path == section.constraint
+	}
+	return filepath.Base(path) == section.constraint
+}
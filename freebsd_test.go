@@ -0,0 +1,142 @@
+package main
+
+import (
+	"encoding/binary"
+	"slices"
+	"testing"
+)
+
+// hints file with the string table right after the header
+func buildElfHints(bo binary.ByteOrder, magic, version uint32, dirs string) []byte {
+	header := make([]byte, elfHintsHeaderSize)
+	bo.PutUint32(header, magic)
+	bo.PutUint32(header[4:], version)
+	bo.PutUint32(header[8:], elfHintsHeaderSize)
+	// unused by rtld, and by the parser
+	bo.PutUint32(header[12:], uint32(len(dirs)+1))
+	bo.PutUint32(header[16:], 0)
+	bo.PutUint32(header[20:], uint32(len(dirs)))
+	return append(append(header, dirs...), 0)
+}
+
+func TestParseElfHints(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    []byte
+		want    []string
+		wantErr bool
+	}{
+		{
+			name: "little-endian",
+			data: buildElfHints(binary.LittleEndian, elfHintsMagic, elfHintsVersion, "/lib:/usr/lib:/usr/local/lib"),
+			want: []string{"/lib", "/usr/lib", "/usr/local/lib"},
+		},
+		{
+			name: "big-endian",
+			data: buildElfHints(binary.BigEndian, elfHintsMagic, elfHintsVersion, "/lib:/usr/lib"),
+			want: []string{"/lib", "/usr/lib"},
+		},
+		{
+			name: "empty entries are dropped",
+			data: buildElfHints(binary.LittleEndian, elfHintsMagic, elfHintsVersion, ":/lib::/usr/lib:"),
+			want: []string{"/lib", "/usr/lib"},
+		},
+		{
+			name: "empty directory list",
+			data: buildElfHints(binary.LittleEndian, elfHintsMagic, elfHintsVersion, ""),
+			want: []string{},
+		},
+		{
+			name:    "unknown magic",
+			data:    buildElfHints(binary.LittleEndian, 0x12345678, elfHintsVersion, "/lib"),
+			wantErr: true,
+		},
+		{
+			name:    "unsupported version",
+			data:    buildElfHints(binary.BigEndian, elfHintsMagic, 2, "/lib"),
+			wantErr: true,
+		},
+		{
+			name:    "truncated header",
+			data:    buildElfHints(binary.LittleEndian, elfHintsMagic, elfHintsVersion, "/lib")[:elfHintsHeaderSize-1],
+			wantErr: true,
+		},
+		{
+			name:    "truncated directory list",
+			data:    buildElfHints(binary.LittleEndian, elfHintsMagic, elfHintsVersion, "/lib:/usr/lib")[:elfHintsHeaderSize+4],
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseElfHints(tt.data)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("parseElfHints = %v, want error", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseElfHints: %v", err)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLibmapLookup(t *testing.T) {
+	root := testRootContents(t, map[string]string{
+		"/etc/libmap.conf": `# defaults apply to every object
+libfoo.so.1	libfoo.so.2
+libbar.so.1 libbar-default.so.1
+
+[/usr/local/bin/]
+libfoo.so.1 libfoo-local.so.1
+
+[app]
+libbar.so.1 libbar-app.so.1
+
+include /etc/libmap.d/extra.conf
+
+[/usr/local/bin/app]
+libbaz.so.1 libbaz-exact.so.1
+`,
+		"/etc/libmap.d/extra.conf": `[/usr/local/bin/tool]
+libfoo.so.1 libfoo-tool.so.1
+`,
+	})
+
+	lm := &libmap{defaults: make(map[string]string)}
+	lm.parseFile(multiPath{rootPath: "/etc/libmap.conf", root: root, mustExist: true}, root, newSet[string]())
+
+	tests := []struct {
+		name   string
+		owner  string
+		soname string
+		want   string
+		wantOK bool
+	}{
+		{name: "default mapping", owner: "/bin/sh", soname: "libfoo.so.1", want: "libfoo.so.2", wantOK: true},
+		{name: "unmapped", owner: "/bin/sh", soname: "libc.so.7"},
+		{name: "directory section", owner: "/usr/local/bin/other", soname: "libfoo.so.1", want: "libfoo-local.so.1", wantOK: true},
+		{name: "directory must be a prefix", owner: "/usr/local/binx/other", soname: "libfoo.so.1", want: "libfoo.so.2", wantOK: true},
+		{name: "basename section", owner: "/opt/app", soname: "libbar.so.1", want: "libbar-app.so.1", wantOK: true},
+		// later sections come first, so the exact path one shadows the basename and directory ones
+		{name: "later exact path section", owner: "/usr/local/bin/app", soname: "libbaz.so.1", want: "libbaz-exact.so.1", wantOK: true},
+		// only the first matching section is used, then the defaults
+		{name: "first matching section lacks mapping", owner: "/usr/local/bin/app", soname: "libbar.so.1", want: "libbar-default.so.1", wantOK: true},
+		{name: "included section", owner: "/usr/local/bin/tool", soname: "libfoo.so.1", want: "libfoo-tool.so.1", wantOK: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := lm.lookup(tt.owner, tt.soname)
+			if got != tt.want || ok != tt.wantOK {
+				t.Errorf("lookup(%q, %q) = %q, %v; want %q, %v", tt.owner, tt.soname, got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
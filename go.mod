@@ -1,3 +1,3 @@
 module github.com/monoidic/ldd-sym
 
-go 1.23.6
+go 1.24
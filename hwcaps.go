@@ -0,0 +1,59 @@
+package main
+
+import (
+	"debug/elf"
+	"fmt"
+	"path/filepath"
+	"slices"
+)
+
+const hwcapsDir = "glibc-hwcaps"
+
+// glibc-hwcaps subdirectories per architecture, from most to least capable
+// based on sysdeps/*/dl-hwcaps-subdirs.c in glibc
+var hwcapsLevels = map[elf.Machine][]string{
+	elf.EM_X86_64: {"x86-64-v4", "x86-64-v3", "x86-64-v2"},
+	elf.EM_PPC64:  {"power11", "power10", "power9"},
+	elf.EM_S390:   {"z17", "z16", "z15", "z14", "z13"},
+}
+
+// subdirectories to search, in priority order, for the given -hwcaps option value
+func getHwcapsSubdirs(level string, machine elf.Machine, class elf.Class) ([]string, error) {
+	if level == "" {
+		return nil, nil
+	}
+
+	levels := hwcapsLevels[machine]
+	if machine == elf.EM_S390 && class != elf.ELFCLASS64 {
+		levels = nil
+	}
+
+	if level == "all" {
+		return levels, nil
+	}
+
+	index := slices.Index(levels, level)
+	if index == -1 {
+		return nil, fmt.Errorf("unknown hwcaps level %q for %s", level, machine)
+	}
+
+	return levels[index:], nil
+}
+
+// directories to search for sonames within dir, in priority order
+func hwcapsDirs(dir string, subdirs []string) []string {
+	ret := make([]string, 0, len(subdirs)+1)
+	for _, subdir := range subdirs {
+		ret = append(ret, filepath.Join(dir, hwcapsDir, subdir))
+	}
+	return append(ret, dir)
+}
+
+// glibc-hwcaps subdirectory the path is in, or empty if none
+func hwcapsOf(path string) string {
+	dir := filepath.Dir(path)
+	if filepath.Base(filepath.Dir(dir)) != hwcapsDir {
+		return ""
+	}
+	return filepath.Base(dir)
+}
@@ -0,0 +1,201 @@
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"iter"
+	"os"
+)
+
+// based on sysdeps/generic/dl-cache.h in glibc
+const (
+	ldCacheMagicOld        = "ld.so-1.7.0"
+	ldCacheMagicNew        = "glibc-ld.so.cache"
+	ldCacheVersionNew      = "1.1"
+	ldCacheHeaderOldSize   = 16
+	ldCacheHeaderNewSize   = 48
+	ldCacheEntryOldSize    = 12
+	ldCacheEntryNewSize    = 24
+	ldCacheFlagTypeMask    = 0x00ff
+	ldCacheEndianLittle    = 2
+	ldCacheEndianBig       = 3
+	ldCacheHwcapsExtension = 1 << 62
+)
+
+// soname to path mapping read from ld.so.cache
+type ldCache struct {
+	entries map[string][]ldCacheEntry
+}
+
+type ldCacheEntry struct {
+	flags int32
+	// path on the target system
+	path  string
+	hwcap uint64
+}
+
+// returns nil if the root has no usable ld.so.cache; newFormat is false for loaders
+// that only read the old format
+func getLdCache(root string, newFormat bool) *ldCache {
+	mp := multiPath{
+		rootPath:  "/etc/ld.so.cache",
+		root:      root,
+		mustExist: true,
+	}
+	if mp.fill() != nil {
+		return nil
+	}
+
+	data, err := os.ReadFile(mp.getReal())
+	if err != nil {
+		return nil
+	}
+
+	cache, err := parseLdCache(data, newFormat)
+	if err != nil {
+		return nil
+	}
+
+	return cache
+}
+
+func parseLdCache(data []byte, newFormat bool) (*ldCache, error) {
+	if newFormat && bytes.HasPrefix(data, []byte(ldCacheMagicNew)) {
+		return parseLdCacheNew(data)
+	}
+
+	if !bytes.HasPrefix(data, []byte(ldCacheMagicOld)) {
+		return nil, errors.New("parseLdCache: unknown magic")
+	}
+
+	if len(data) < ldCacheHeaderOldSize {
+		return nil, errors.New("parseLdCache: truncated header")
+	}
+
+	// the old format is always in the native byte order of the system it was generated on
+	var byteOrder binary.ByteOrder
+	var nlibs int
+	for _, bo := range []binary.ByteOrder{binary.LittleEndian, binary.BigEndian} {
+		n := int(bo.Uint32(data[12:]))
+		if ldCacheHeaderOldSize+n*ldCacheEntryOldSize <= len(data) {
+			byteOrder = bo
+			nlibs = n
+			break
+		}
+	}
+	if byteOrder == nil {
+		return nil, errors.New("parseLdCache: truncated entries")
+	}
+
+	// a new format cache may follow the old one for compatibility, prefer it if present
+	strTabOff := ldCacheHeaderOldSize + nlibs*ldCacheEntryOldSize
+	newOff := (strTabOff + 7) &^ 7
+	for _, off := range []int{strTabOff, newOff} {
+		if newFormat && off < len(data) && bytes.HasPrefix(data[off:], []byte(ldCacheMagicNew)) {
+			return parseLdCacheNew(data[off:])
+		}
+	}
+
+	cache := &ldCache{entries: make(map[string][]ldCacheEntry)}
+	strTab := data[strTabOff:]
+	for i := range nlibs {
+		entry := data[ldCacheHeaderOldSize+i*ldCacheEntryOldSize:]
+		key, okKey := cString(strTab, byteOrder.Uint32(entry[4:]))
+		value, okValue := cString(strTab, byteOrder.Uint32(entry[8:]))
+		if !(okKey && okValue) {
+			return nil, errors.New("parseLdCache: invalid string offset")
+		}
+		cache.add(key, ldCacheEntry{
+			flags: int32(byteOrder.Uint32(entry)),
+			path:  value,
+		})
+	}
+
+	return cache, nil
+}
+
+func parseLdCacheNew(data []byte) (*ldCache, error) {
+	if len(data) < ldCacheHeaderNewSize {
+		return nil, errors.New("parseLdCacheNew: truncated header")
+	}
+
+	if string(data[len(ldCacheMagicNew):len(ldCacheMagicNew)+len(ldCacheVersionNew)]) != ldCacheVersionNew {
+		return nil, errors.New("parseLdCacheNew: unsupported version")
+	}
+
+	var byteOrder binary.ByteOrder = binary.LittleEndian
+	if data[28] == ldCacheEndianBig {
+		byteOrder = binary.BigEndian
+	}
+
+	nlibs := int(byteOrder.Uint32(data[20:]))
+	if ldCacheHeaderNewSize+nlibs*ldCacheEntryNewSize > len(data) {
+		return nil, errors.New("parseLdCacheNew: truncated entries")
+	}
+
+	cache := &ldCache{entries: make(map[string][]ldCacheEntry)}
+	// string offsets are relative to the start of the header
+	for i := range nlibs {
+		entry := data[ldCacheHeaderNewSize+i*ldCacheEntryNewSize:]
+		key, okKey := cString(data, byteOrder.Uint32(entry[4:]))
+		value, okValue := cString(data, byteOrder.Uint32(entry[8:]))
+		if !(okKey && okValue) {
+			return nil, errors.New("parseLdCacheNew: invalid string offset")
+		}
+		cache.add(key, ldCacheEntry{
+			flags: int32(byteOrder.Uint32(entry)),
+			path:  value,
+			hwcap: byteOrder.Uint64(entry[16:]),
+		})
+	}
+
+	return cache, nil
+}
+
+func (cache *ldCache) add(soname string, entry ldCacheEntry) {
+	// a.out libraries, not loadable by the ELF loader
+	if entry.flags&ldCacheFlagTypeMask == 0 {
+		return
+	}
+	cache.entries[soname] = append(cache.entries[soname], entry)
+}
+
+// paths for the soname, glibc-hwcaps entries in priority order first, then in cache order;
+// architecture compatibility is left to the caller
+func (cache *ldCache) lookup(soname, root string, hwcaps []string) iter.Seq[multiPath] {
+	entries := cache.entries[soname]
+	paths := func(yield func(string) bool) {
+		for _, subdir := range hwcaps {
+			for _, entry := range entries {
+				if entry.hwcap&ldCacheHwcapsExtension != 0 && hwcapsOf(entry.path) == subdir {
+					if !yield(entry.path) {
+						return
+					}
+				}
+			}
+		}
+
+		for _, entry := range entries {
+			if entry.hwcap&ldCacheHwcapsExtension == 0 {
+				if !yield(entry.path) {
+					return
+				}
+			}
+		}
+	}
+
+	return rootedToMultiPath(paths, root, true)
+}
+
+func cString(data []byte, off uint32) (string, bool) {
+	if uint64(off) >= uint64(len(data)) {
+		return "", false
+	}
+	data = data[off:]
+	end := bytes.IndexByte(data, 0)
+	if end == -1 {
+		return "", false
+	}
+	return string(data[:end]), true
+}
@@ -0,0 +1,228 @@
+package main
+
+import (
+	"encoding/binary"
+	"slices"
+	"testing"
+)
+
+type testCacheEntry struct {
+	flags int32
+	key   string
+	value string
+	hwcap uint64
+}
+
+// old format cache with the string table right after the entries
+func buildLdCacheOld(bo binary.ByteOrder, entries []testCacheEntry) []byte {
+	header := make([]byte, ldCacheHeaderOldSize)
+	copy(header, ldCacheMagicOld)
+	bo.PutUint32(header[12:], uint32(len(entries)))
+
+	var strTab []byte
+	table := make([]byte, len(entries)*ldCacheEntryOldSize)
+	for i, e := range entries {
+		entry := table[i*ldCacheEntryOldSize:]
+		bo.PutUint32(entry, uint32(e.flags))
+		bo.PutUint32(entry[4:], uint32(len(strTab)))
+		strTab = append(append(strTab, e.key...), 0)
+		bo.PutUint32(entry[8:], uint32(len(strTab)))
+		strTab = append(append(strTab, e.value...), 0)
+	}
+
+	return slices.Concat(header, table, strTab)
+}
+
+// new format cache; string offsets are relative to the header
+func buildLdCacheNew(bo binary.ByteOrder, entries []testCacheEntry) []byte {
+	header := make([]byte, ldCacheHeaderNewSize)
+	copy(header, ldCacheMagicNew)
+	copy(header[len(ldCacheMagicNew):], ldCacheVersionNew)
+	bo.PutUint32(header[20:], uint32(len(entries)))
+	header[28] = ldCacheEndianLittle
+	if bo == binary.BigEndian {
+		header[28] = ldCacheEndianBig
+	}
+
+	strOff := ldCacheHeaderNewSize + len(entries)*ldCacheEntryNewSize
+	var strTab []byte
+	table := make([]byte, len(entries)*ldCacheEntryNewSize)
+	for i, e := range entries {
+		entry := table[i*ldCacheEntryNewSize:]
+		bo.PutUint32(entry, uint32(e.flags))
+		bo.PutUint32(entry[4:], uint32(strOff+len(strTab)))
+		strTab = append(append(strTab, e.key...), 0)
+		bo.PutUint32(entry[8:], uint32(strOff+len(strTab)))
+		strTab = append(append(strTab, e.value...), 0)
+		bo.PutUint64(entry[16:], e.hwcap)
+	}
+
+	return slices.Concat(header, table, strTab)
+}
+
+// old format entries followed by a new format cache, as written by ldconfig -c compat;
+// the old string offsets are relative to the end of the old entries, with the strings at the end
+func buildLdCacheCompat(oldEntries, newEntries []testCacheEntry) []byte {
+	bo := binary.LittleEndian
+	header := make([]byte, ldCacheHeaderOldSize)
+	copy(header, ldCacheMagicOld)
+	bo.PutUint32(header[12:], uint32(len(oldEntries)))
+	table := make([]byte, len(oldEntries)*ldCacheEntryOldSize)
+	strTabOff := len(header) + len(table)
+
+	pad := make([]byte, (strTabOff+7)&^7-strTabOff)
+	newCache := buildLdCacheNew(bo, newEntries)
+	strOff := len(pad) + len(newCache)
+
+	var strTab []byte
+	for i, e := range oldEntries {
+		entry := table[i*ldCacheEntryOldSize:]
+		bo.PutUint32(entry, uint32(e.flags))
+		bo.PutUint32(entry[4:], uint32(strOff+len(strTab)))
+		strTab = append(append(strTab, e.key...), 0)
+		bo.PutUint32(entry[8:], uint32(strOff+len(strTab)))
+		strTab = append(append(strTab, e.value...), 0)
+	}
+
+	return slices.Concat(header, table, pad, newCache, strTab)
+}
+
+func TestParseLdCache(t *testing.T) {
+	const elfLibc6 = 0x0303 // FLAG_ELF_LIBC6 | FLAG_X8664_LIB64
+	libc := testCacheEntry{flags: elfLibc6, key: "libc.so.6", value: "/lib64/libc.so.6"}
+	libm := testCacheEntry{flags: elfLibc6, key: "libm.so.6", value: "/lib64/libm.so.6"}
+	hwcapsLibm := testCacheEntry{flags: elfLibc6, key: "libm.so.6", value: "/lib64/glibc-hwcaps/x86-64-v3/libm.so.6", hwcap: ldCacheHwcapsExtension}
+	aout := testCacheEntry{flags: 0, key: "libold.so.1", value: "/lib/libold.so.1"}
+
+	tests := []struct {
+		name      string
+		data      []byte
+		newFormat bool
+		want      map[string][]string
+		wantErr   bool
+	}{
+		{
+			name:      "old little-endian",
+			data:      buildLdCacheOld(binary.LittleEndian, []testCacheEntry{libc, libm}),
+			newFormat: true,
+			want:      map[string][]string{"libc.so.6": {"/lib64/libc.so.6"}, "libm.so.6": {"/lib64/libm.so.6"}},
+		},
+		{
+			name:      "old big-endian",
+			data:      buildLdCacheOld(binary.BigEndian, []testCacheEntry{libc}),
+			newFormat: true,
+			want:      map[string][]string{"libc.so.6": {"/lib64/libc.so.6"}},
+		},
+		{
+			name:      "old skips a.out entries",
+			data:      buildLdCacheOld(binary.LittleEndian, []testCacheEntry{aout, libc}),
+			newFormat: true,
+			want:      map[string][]string{"libc.so.6": {"/lib64/libc.so.6"}},
+		},
+		{
+			name:      "new little-endian",
+			data:      buildLdCacheNew(binary.LittleEndian, []testCacheEntry{hwcapsLibm, libm}),
+			newFormat: true,
+			want:      map[string][]string{"libm.so.6": {hwcapsLibm.value, libm.value}},
+		},
+		{
+			name:      "new big-endian",
+			data:      buildLdCacheNew(binary.BigEndian, []testCacheEntry{libc}),
+			newFormat: true,
+			want:      map[string][]string{"libc.so.6": {"/lib64/libc.so.6"}},
+		},
+		{
+			name:    "new unsupported by the loader",
+			data:    buildLdCacheNew(binary.LittleEndian, []testCacheEntry{libc}),
+			wantErr: true,
+		},
+		{
+			name:      "compat prefers the new format",
+			data:      buildLdCacheCompat([]testCacheEntry{libc}, []testCacheEntry{libm}),
+			newFormat: true,
+			want:      map[string][]string{"libm.so.6": {"/lib64/libm.so.6"}},
+		},
+		{
+			name: "compat read as old format",
+			data: buildLdCacheCompat([]testCacheEntry{libc}, []testCacheEntry{libm}),
+			want: map[string][]string{"libc.so.6": {"/lib64/libc.so.6"}},
+		},
+		{
+			name:    "unknown magic",
+			data:    []byte("not a cache at all"),
+			wantErr: true,
+		},
+		{
+			name:      "old truncated header",
+			data:      []byte(ldCacheMagicOld),
+			newFormat: true,
+			wantErr:   true,
+		},
+		{
+			name:      "old truncated entries",
+			data:      buildLdCacheOld(binary.LittleEndian, []testCacheEntry{libc, libm})[:ldCacheHeaderOldSize+ldCacheEntryOldSize],
+			newFormat: true,
+			wantErr:   true,
+		},
+		{
+			name:      "old truncated strings",
+			data:      buildLdCacheOld(binary.LittleEndian, []testCacheEntry{libc})[:ldCacheHeaderOldSize+ldCacheEntryOldSize+4],
+			newFormat: true,
+			wantErr:   true,
+		},
+		{
+			name:      "new truncated header",
+			data:      buildLdCacheNew(binary.LittleEndian, nil)[:ldCacheHeaderNewSize-1],
+			newFormat: true,
+			wantErr:   true,
+		},
+		{
+			name:      "new truncated entries",
+			data:      buildLdCacheNew(binary.LittleEndian, []testCacheEntry{libc})[:ldCacheHeaderNewSize+ldCacheEntryNewSize-1],
+			newFormat: true,
+			wantErr:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cache, err := parseLdCache(tt.data, tt.newFormat)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("parseLdCache succeeded, want error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseLdCache: %v", err)
+			}
+
+			got := make(map[string][]string)
+			for soname, entries := range cache.entries {
+				for _, entry := range entries {
+					got[soname] = append(got[soname], entry.path)
+				}
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("got %v, want %v", got, tt.want)
+			}
+			for soname, paths := range tt.want {
+				if !slices.Equal(got[soname], paths) {
+					t.Errorf("%s: got %v, want %v", soname, got[soname], paths)
+				}
+			}
+		})
+	}
+}
+
+func TestParseLdCacheNewHwcap(t *testing.T) {
+	entry := testCacheEntry{flags: 0x0303, key: "libm.so.6", value: "/lib64/glibc-hwcaps/x86-64-v3/libm.so.6", hwcap: ldCacheHwcapsExtension | 2}
+	cache, err := parseLdCache(buildLdCacheNew(binary.BigEndian, []testCacheEntry{entry}), true)
+	if err != nil {
+		t.Fatalf("parseLdCache: %v", err)
+	}
+	got := cache.entries["libm.so.6"]
+	if len(got) != 1 || got[0].hwcap != entry.hwcap || got[0].flags != entry.flags {
+		t.Errorf("got %+v, want hwcap %#x and flags %#x", got, entry.hwcap, entry.flags)
+	}
+}
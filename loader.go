@@ -0,0 +1,296 @@
+package main
+
+import (
+	"bytes"
+	"debug/elf"
+	"fmt"
+	"iter"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// step in a loader's soname search order
+type phaseKind int
+
+const (
+	// DT_RPATH of the needing object and the objects that loaded it, down to the executable
+	phaseRpath phaseKind = iota
+	// DT_RPATH of the needing object only
+	phaseRpathOwn
+	// DT_RPATH of the executable
+	phaseRpathExecutable
+	phaseLdLibraryPath
+	phaseRunpath
+	// ld.so.cache or equivalent
+	phaseCache
+	// directory the loader itself is in
+	phaseLoaderDir
+	phaseDefault
+)
+
+// how symbol versions affect binding
+type versionPolicy int
+
+const (
+	// match name and version like glibc
+	versionsFull versionPolicy = iota
+	// ignore versions, but never bind to non-default ones
+	versionsIgnoreHidden
+	// no symbol versioning support at all
+	versionsIgnore
+)
+
+// resolution policy of a dynamic linker
+type loaderProfile struct {
+	name string
+	// in lookup order; DT_RPATH phases are skipped if the needing object has DT_RUNPATH,
+	// and phaseCache, phaseLoaderDir and phaseDefault if it has DF_1_NODEFLIB
+	phases []phaseKind
+	// DT_RUNPATH is used in place of DT_RPATH, with the same semantics
+	runpathAsRpath bool
+	// DT_RPATH is not supported
+	ignoreRpath bool
+	// DF_1_NODEFLIB is supported
+	nodeflib bool
+	// DT_SYMBOLIC is supported
+	symbolic bool
+	// fall back to ld.so.conf if there is no cache
+	ldSoConf bool
+	// /etc/ld.so.preload is read
+	preloadFile bool
+	// DT_AUDIT and DT_DEPAUDIT are supported
+	audit bool
+	// non-ELF candidates and those with a bad byte order, OSABI or ABI version end the search with an error
+	// instead of being skipped
+	fatalHeaders bool
+	// in secure mode, $ORIGIN and LD_PRELOAD entries without slashes are allowed within the default directories;
+	// otherwise both are ignored
+	secureTrusted bool
+
+	dstTokens []string
+	// whether tokens are expanded in DT_NEEDED and LD_LIBRARY_PATH
+	expandNeeded        bool
+	expandLdLibraryPath bool
+	// characters separating LD_LIBRARY_PATH entries
+	pathSeps string
+
+	versions versionPolicy
+	// STT_GNU_IFUNC definitions are supported
+	ifunc  bool
+	hwcaps bool
+	// Android linker namespaces
+	namespaces bool
+	// the vDSO is in the lookup scope
+	vdso bool
+
+	// nil if the loader has no cache
+	cache func(base *baseInfo) *ldCache
+	// directory list searched in the cache phase, for loaders without a soname cache; nil if unsupported
+	hints func(base *baseInfo) *elfHints
+	// DT_NEEDED remappings; nil if unsupported
+	libmap      func(base *baseInfo) *libmap
+	defaultDirs func(base *baseInfo) iter.Seq[multiPath]
+	// value of $LIB; nil if unsupported
+	dstLib func(base *baseInfo) string
+	// whether the PT_INTERP basename belongs to this loader
+	matchInterp func(name string) bool
+}
+
+var (
+	profileGlibc = &loaderProfile{
+		name:                "glibc",
+		phases:              []phaseKind{phaseRpath, phaseLdLibraryPath, phaseRunpath, phaseCache, phaseDefault},
+		preloadFile:         true,
+		secureTrusted:       true,
+		audit:               true,
+		fatalHeaders:        true,
+		nodeflib:            true,
+		symbolic:            true,
+		ldSoConf:            true,
+		dstTokens:           []string{"ORIGIN", "LIB", "PLATFORM"},
+		expandNeeded:        true,
+		expandLdLibraryPath: true,
+		pathSeps:            ":;",
+		versions:            versionsFull,
+		ifunc:               true,
+		hwcaps:              true,
+		vdso:                true,
+		cache:               func(base *baseInfo) *ldCache { return getLdCache(base.options.root, true) },
+		defaultDirs:         func(base *baseInfo) iter.Seq[multiPath] { return getSearchDirCachedStd(base.options.root, base.abi) },
+		dstLib: func(base *baseInfo) string {
+			return getDstLib(base.options.root, base.abi)
+		},
+		matchInterp: func(name string) bool {
+			return strings.HasPrefix(name, "ld-linux") || strings.HasPrefix(name, "ld64.so.") || name == "ld.so.1"
+		},
+	}
+
+	// based on load_library in ldso/dynlink.c; the vDSO is attached after the global namespace is built,
+	// so it never provides symbols to the program
+	profileMusl = &loaderProfile{
+		name:           "musl",
+		phases:         []phaseKind{phaseLdLibraryPath, phaseRpath, phaseDefault},
+		runpathAsRpath: true,
+		dstTokens:      []string{"ORIGIN"},
+		pathSeps:       ":\n",
+		versions:       versionsIgnoreHidden,
+		defaultDirs:    func(base *baseInfo) iter.Seq[multiPath] { return base.getMuslSysPath() },
+		matchInterp: func(name string) bool {
+			_, ok := muslArch(name)
+			return ok
+		},
+	}
+
+	// based on find_libraries in linker/linker.cpp
+	profileBionic = &loaderProfile{
+		name:        "bionic",
+		phases:      []phaseKind{phaseLdLibraryPath, phaseRunpath, phaseDefault},
+		ignoreRpath: true,
+		dstTokens:   []string{"ORIGIN", "LIB"},
+		pathSeps:    ":",
+		versions:    versionsFull,
+		ifunc:       true,
+		namespaces:  true,
+		symbolic:    true,
+		vdso:        true,
+		defaultDirs: func(base *baseInfo) iter.Seq[multiPath] { return getSearchDirCachedAndroid(base.options.root) },
+		dstLib:      func(base *baseInfo) string { return bionicLib(base.class) },
+		matchInterp: func(name string) bool {
+			return name == "linker" || name == "linker64"
+		},
+	}
+
+	// based on _dl_load_shared_library in ldso/ldso/dl-elf.c
+	profileUclibc = &loaderProfile{
+		name:          "uclibc",
+		phases:        []phaseKind{phaseRpathOwn, phaseLdLibraryPath, phaseRunpath, phaseCache, phaseLoaderDir, phaseDefault},
+		preloadFile:   true,
+		secureTrusted: true,
+		dstTokens:     []string{"ORIGIN"},
+		pathSeps:      ":",
+		versions:      versionsIgnore,
+		// ldconfig from uClibc only writes the old format
+		cache:       func(base *baseInfo) *ldCache { return getLdCache(base.options.root, false) },
+		defaultDirs: func(base *baseInfo) iter.Seq[multiPath] { return getSearchDirCachedUclibc(base.options.root) },
+		matchInterp: func(name string) bool {
+			return strings.HasPrefix(name, "ld-uClibc") || strings.HasPrefix(name, "ld64-uClibc")
+		},
+	}
+
+	// based on find_library in libexec/rtld-elf/rtld.c
+	profileFreeBSD = &loaderProfile{
+		name:                "freebsd",
+		phases:              []phaseKind{phaseRpathOwn, phaseRpathExecutable, phaseLdLibraryPath, phaseRunpath, phaseCache, phaseDefault},
+		dstTokens:           []string{"ORIGIN", "PLATFORM"},
+		expandNeeded:        true,
+		expandLdLibraryPath: true,
+		pathSeps:            ":;",
+		nodeflib:            true,
+		symbolic:            true,
+		versions:            versionsFull,
+		ifunc:               true,
+		hints:               func(base *baseInfo) *elfHints { return base.getElfHints() },
+		libmap:              func(base *baseInfo) *libmap { return base.getLibmap() },
+		defaultDirs:         func(base *baseInfo) iter.Seq[multiPath] { return base.getSearchDirCachedFreeBSD() },
+		matchInterp: func(name string) bool {
+			return name == "ld-elf.so.1" || name == "ld-elf32.so.1"
+		},
+	}
+
+	loaderProfiles = []*loaderProfile{profileGlibc, profileMusl, profileBionic, profileUclibc, profileFreeBSD}
+)
+
+// pick the loader from the -loader option, or from PT_INTERP and EI_OSABI if unset
+func (base *baseInfo) getLoader(f *elf.File) error {
+	if name := base.options.loader; name != "" {
+		for _, profile := range loaderProfiles {
+			if profile.name == name {
+				base.loader = profile
+				return nil
+			}
+		}
+		return fmt.Errorf("unknown loader %q", name)
+	}
+
+	if base.interpPath != "" {
+		name := filepath.Base(base.interpPath)
+		for _, profile := range loaderProfiles {
+			if profile.matchInterp(name) {
+				base.loader = profile
+				return nil
+			}
+		}
+	}
+
+	switch {
+	case f.OSABI == elf.ELFOSABI_FREEBSD:
+		base.loader = profileFreeBSD
+	case base.options.android:
+		base.loader = profileBionic
+	default:
+		base.loader = profileGlibc
+	}
+
+	return nil
+}
+
+func loaderNames() string {
+	names := seqMap(slices.Values(loaderProfiles), func(profile *loaderProfile) (string, bool) {
+		return fmt.Sprintf("%q", profile.name), true
+	})
+	return strings.Join(slices.Collect(names), ", ")
+}
+
+func (profile *loaderProfile) splitPath(s string) iter.Seq[string] {
+	return slices.Values(strings.FieldsFunc(s, func(r rune) bool {
+		return strings.ContainsRune(profile.pathSeps, r)
+	}))
+}
+
+// $ARCH of a /lib/ld-musl-$ARCH.so.1 interpreter path
+func muslArch(interp string) (string, bool) {
+	name := filepath.Base(interp)
+	arch, ok := strings.CutPrefix(name, "ld-musl-")
+	if !ok {
+		return "", false
+	}
+	return strings.CutSuffix(arch, ".so.1")
+}
+
+func (base *baseInfo) getMuslSysPath() iter.Seq[multiPath] {
+	root := base.options.root
+
+	arch, ok := muslArch(base.interpPath)
+	if ok {
+		// relative to the prefix the loader is installed in, e.g. /usr/local/musl for /usr/local/musl/lib/ld-musl-x86_64.so.1
+		prefix := filepath.Dir(filepath.Dir(base.interpPath))
+		mp := multiPath{
+			rootPath:  filepath.Join(prefix, "etc", "ld-musl-"+arch+".path"),
+			root:      root,
+			mustExist: true,
+		}
+		if mp.fill() == nil {
+			if data, err := os.ReadFile(mp.getReal()); err == nil {
+				return rootedToMultiPath(base.loader.splitPath(string(bytes.TrimSpace(data))), root, true)
+			}
+		}
+	}
+
+	paths := []string{"/lib", "/usr/local/lib", "/usr/lib"}
+	return rootedToMultiPath(slices.Values(paths), root, true)
+}
+
+func getSearchDirCachedUclibc(root string) iter.Seq[multiPath] {
+	paths := []string{"/lib", "/usr/lib"}
+	return rootedToMultiPath(slices.Values(paths), root, true)
+}
+
+// value of ${LIB} for bionic
+func bionicLib(class elf.Class) string {
+	if class == elf.ELFCLASS64 {
+		return "lib64"
+	}
+	return "lib"
+}
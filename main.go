@@ -1,13 +1,16 @@
 package main
 
 import (
+	"bytes"
 	"debug/elf"
 	"encoding/json"
 	"errors"
 	"flag"
 	"fmt"
+	"io"
 	"iter"
 	"log"
+	"maps"
 	"os"
 	"path/filepath"
 	"runtime"
@@ -17,11 +20,16 @@ import (
 )
 
 func parseBase(options *parseOptions) (*baseInfo, error) {
-	f, err := elf.Open(options.elfPath.getReal())
+	rawF, err := os.Open(options.elfPath.getReal())
+	if err != nil {
+		return nil, err
+	}
+	defer rawF.Close()
+
+	f, err := elf.NewFile(rawF)
 	if err != nil {
 		return nil, err
 	}
-	defer f.Close()
 
 	dynSyms, err := f.DynamicSymbols()
 	if err != nil {
@@ -33,18 +41,53 @@ func parseBase(options *parseOptions) (*baseInfo, error) {
 		return nil, fmt.Errorf("parseBase DT_NEEDED: %w", err)
 	}
 
-	syms := getDynSyms(slices.Values(dynSyms), options)
-	runpath := getRunPath(f, options.elfPath)
+	syms := getDynSyms(slices.Values(dynSyms), options, false)
 
 	bi := &baseInfo{
 		syms:    syms,
 		sonames: sonames,
-		runpath: runpath,
 		options: options,
 		machine: f.Machine,
 		class:   f.Class,
+		data:    f.Data,
 	}
+	bi.abi, err = newABIFingerprint(f, rawF)
+	if err != nil {
+		return nil, fmt.Errorf("parseBase: %w", err)
+	}
+
 	bi.getInterp(f)
+	if err := bi.getLoader(f); err != nil {
+		return nil, fmt.Errorf("parseBase loader: %w", err)
+	}
+	bi.exported = uniq(seqMap(slices.Values(dynSyms), func(sym elf.Symbol) (symbol, bool) {
+		return newSymbol(sym), bi.isEligibleDef(sym)
+	}))
+
+	if bi.loader.dstLib != nil {
+		bi.dstLib = bi.loader.dstLib(bi)
+	}
+	bi.platform = options.platform
+	if bi.platform == "" {
+		bi.platform = defaultPlatforms[f.Machine]
+	}
+	bi.secure, err = getSecureReason(options.secure, options.elfPath)
+	if err != nil {
+		return nil, fmt.Errorf("parseBase: %w", err)
+	}
+	bi.dynInfo = getDynInfo(f, options.elfPath, bi)
+	bi.preloads = bi.getPreloads()
+	if bi.loader.audit {
+		for _, tag := range []elf.DynTag{elf.DT_AUDIT, elf.DT_DEPAUDIT} {
+			values, err := dynStrings(f, tag)
+			if err != nil {
+				return nil, fmt.Errorf("parseBase %s: %w", tag, err)
+			}
+			for _, value := range values {
+				bi.audit = append(bi.audit, strings.FieldsFunc(value, func(r rune) bool { return r == ':' })...)
+			}
+		}
+	}
 
 	return bi, nil
 }
@@ -77,34 +120,81 @@ func (base *baseInfo) getInterp(f *elf.File) {
 	base.interpPath = interp
 }
 
-func getDynSyms(seq iter.Seq[elf.Symbol], options *parseOptions) []string {
-	return slices.Collect(seqMap(seq, func(sym elf.Symbol) (string, bool) {
+// the linker leaves references STT_NOTYPE if it saw no definition for them, as for weak ones like __gmon_start__
+// or in shared libraries linked without their dependencies; such weak references, and all of them if untyped is set,
+// are kept if functions or objects are tracked, while other untyped references need -other
+func getDynSyms(seq iter.Seq[elf.Symbol], options *parseOptions, untyped bool) []symbol {
+	return uniq(seqMap(seq, func(sym elf.Symbol) (symbol, bool) {
 		stt := elf.ST_TYPE(sym.Info)
-		isFunc := stt == elf.STT_FUNC
+		isFunc := stt == elf.STT_FUNC || stt == elf.STT_GNU_IFUNC
 		isObj := stt == elf.STT_OBJECT
+		isTLS := stt == elf.STT_TLS
 		stb := elf.ST_BIND(sym.Info)
 		isWeak := stb == elf.STB_WEAK
+		isUntyped := stt == elf.STT_NOTYPE && (untyped || isWeak) && (options.getFunc || options.getObject)
 
 		// does not match argument filters
-		if !((options.getFunc && isFunc) || (options.getObject && isObj) || (options.getOther && !(isFunc || isObj))) {
-			return "", false
+		if !((options.getFunc && isFunc) || (options.getObject && isObj) || (options.getTLS && isTLS) || isUntyped || (options.getOther && !(isFunc || isObj || isTLS))) {
+			return symbol{}, false
 		}
 
 		// defined within this file
 		if sym.Section != elf.SHN_UNDEF {
-			return "", false
+			return symbol{}, false
 		}
 
 		// weak symbol
 		if isWeak && !options.getWeak {
-			return "", false
+			return symbol{}, false
 		}
 
-		return sym.Name, true
+		ref := newSymbol(sym)
+		ref.weak = isWeak
+		return ref, true
 	}))
 }
 
-func getRunPath(f *elf.File, fPath multiPath) []multiPath {
+func getDynInfo(f *elf.File, fPath multiPath, base *baseInfo) dynInfo {
+	var info dynInfo
+	origin := getOrigin(fPath)
+
+	profile := base.loader
+	expand := func(dirs iter.Seq[string]) []multiPath {
+		return slices.Collect(uniqExistsPath(rootedToMultiPath(base.expandDSTs(dirs, origin), fPath.root, true)))
+	}
+
+	// DT_RPATH is ignored if DT_RUNPATH is present
+	dirs, hasRunpath := readRunPath(f, elf.DT_RUNPATH)
+	switch {
+	case profile.runpathAsRpath:
+		// musl treats either one as DT_RPATH
+		if !hasRunpath {
+			dirs, _ = readRunPath(f, elf.DT_RPATH)
+		}
+		info.rpath = expand(dirs)
+	case hasRunpath:
+		info.hasRunpath = true
+		info.runpath = expand(dirs)
+	case !profile.ignoreRpath:
+		dirs, _ = readRunPath(f, elf.DT_RPATH)
+		info.rpath = expand(dirs)
+	}
+
+	if flags, err := f.DynValue(elf.DT_FLAGS); err == nil && len(flags) > 0 {
+		info.flags = elf.DynFlag(flags[0])
+	}
+	if flags, err := f.DynValue(elf.DT_FLAGS_1); err == nil && len(flags) > 0 {
+		info.flags1 = elf.DynFlag1(flags[0])
+	}
+	info.nodeflib = profile.nodeflib && info.flags1&elf.DF_1_NODEFLIB != 0
+	symbolic, err := f.DynValue(elf.DT_SYMBOLIC)
+	info.symbolic = profile.symbolic && ((err == nil && len(symbolic) > 0) || info.flags&elf.DF_SYMBOLIC != 0)
+
+	return info
+}
+
+// rooted directory containing the object, for $ORIGIN
+func getOrigin(fPath multiPath) string {
 	origin := multiPath{
 		rootPath:  filepath.Dir(fPath.getRooted()),
 		root:      fPath.root,
@@ -112,69 +202,190 @@ func getRunPath(f *elf.File, fPath multiPath) []multiPath {
 	}
 
 	check(origin.fill())
-
-	dirs := readRunPath(f, fPath.root)
-	dirs = originize(dirs, origin)
-	dirs = uniqExistsPath(dirs)
-
-	return slices.Collect(dirs)
-}
-
-func originize(seq iter.Seq[multiPath], origin multiPath) iter.Seq[multiPath] {
-	return seqMap(seq, func(dir multiPath) (multiPath, bool) {
-		if !strings.Contains(dir.getRooted(), "$ORIGIN") {
-			return dir, true
-		}
-		dir.rootPath = strings.Replace(dir.getRooted(), "$ORIGIN", origin.getRooted(), -1)
-		dir.realPath = ""
-		check(dir.fill())
-		return dir, true
-	})
+	return origin.getRooted()
 }
 
-func readRunPath(f *elf.File, root string) iter.Seq[multiPath] {
-	for _, symTag := range []elf.DynTag{elf.DT_RUNPATH, elf.DT_RPATH} {
-		runpath, err := f.DynString(symTag)
-		if err == nil && len(runpath) != 0 {
-			return rootedToMultiPath(slices.Values(strings.Split(runpath[0], ":")), root, true)
-		}
+func readRunPath(f *elf.File, tag elf.DynTag) (iter.Seq[string], bool) {
+	runpath, err := f.DynString(tag)
+	if err == nil && len(runpath) != 0 {
+		return slices.Values(strings.Split(runpath[0], ":")), true
 	}
 
-	return emptySeq[multiPath]
+	return emptySeq[string], false
 }
 
-func (base *baseInfo) getSymMatches(searchdirs []multiPath) error {
+func (base *baseInfo) getSymMatches() error {
 	base.symnameToSonames = make(map[string][]string, len(base.syms))
-	requiredSymnames := seqToSet(slices.Values(base.syms))
+	base.deniedSonames = make(map[string]string)
+	base.versionMismatches = newSet[string]()
+	base.objectFlags = make(map[string][]string)
+	base.rejected = make(map[string][]rejection)
+	base.recordFlags(base.options.elfPath, &base.dynInfo)
+	base.symbolDefs = make(map[string]symbol, len(base.syms))
+	base.loaded = make(map[string]*loadedObject)
+	base.libmapped = make(map[string]string)
+	base.shadowedPaths = make(map[string][]sonamePath)
+	base.missingSonames = make(map[string]missingSoname)
+	base.sonameMismatches = make(map[string]sonamePath)
+	base.libraryRefs = make(map[string]refResolution)
+	// rooted paths of the objects needing each soname, including those needing it after it was first queued
+	neededBy := make(map[string][]string)
+	base.scope = []providedSyms{{soname: base.options.elfPath.getRooted(), syms: base.exported}}
+	requiredSyms := make(map[string][]symbol, len(base.syms))
+	for _, sym := range base.syms {
+		requiredSyms[sym.name] = append(requiredSyms[sym.name], sym)
+	}
 
+	// sonames are processed breadth-first like _dl_map_object_deps, which is also the
+	// lookup scope order: DT_NEEDED of the executable in order, then their dependencies
 	seenSonames := newSet[string]()
 	var sonameQueue queue[sonameWithSearchdirs]
 
+	baseOrigin := getOrigin(base.options.elfPath)
+	baseRpaths := []rpathEntry{{owner: base.options.elfPath, dirs: base.rpath}}
+	searchdirs := getSearchdirs(base.options.elfPath, base.dynInfo, baseRpaths, base)
+	// preloaded objects come before DT_NEEDED, and are searched for as if needed by the executable;
+	// they are queued on their own, as one the loader refuses does not keep a DT_NEEDED entry with the same name from loading
+	secureSearchdirs := getSearchdirs(base.options.elfPath, dynInfo{}, nil, base)
+	for i, soname := range base.preloads {
+		element := sonameWithSearchdirs{
+			soname:     soname,
+			searchdirs: searchdirs,
+			origin:     baseOrigin,
+			owner:      base.options.elfPath,
+			rpaths:     baseRpaths,
+			namespace:  androidDefaultNamespace,
+			preload:    "/etc/ld.so.preload",
+		}
+		if i < base.envPreloads {
+			element.preload = "LD_PRELOAD"
+			if base.secure != "" {
+				element.searchdirs = secureSearchdirs
+			}
+		}
+		sonameQueue.push(element)
+	}
 	for _, soname := range base.sonames {
+		if seenSonames.contains(soname) {
+			continue
+		}
+		neededBy[soname] = append(neededBy[soname], base.options.elfPath.getRooted())
 		sonameQueue.push(sonameWithSearchdirs{
 			soname:     soname,
 			searchdirs: searchdirs,
+			origin:     baseOrigin,
+			owner:      base.options.elfPath,
+			rpaths:     baseRpaths,
+			namespace:  androidDefaultNamespace,
 		})
 		seenSonames.add(soname)
 	}
+	// names of the preloaded objects, which later entries with the same name reuse
+	preloaded := newSet[string]()
 
 	unneededSonames := slices.Clone(base.sonames)
+	markNeeded := func(soname string) {
+		if index := slices.Index(unneededSonames, soname); index != -1 {
+			unneededSonames = slices.Delete(unneededSonames, index, index+1)
+		}
+	}
 
-	sonamePaths := make(map[string][]multiPath)
+	sonamePaths := make(map[string][]sonamePath)
 
+	// nil if there is no interpreter, or it is missing from the root
+	var interpPath *multiPath
 	if base.interpPath != "" {
 		mp := multiPath{
 			rootPath:  base.interpPath,
 			root:      base.options.root,
 			mustExist: true,
 		}
-		check(mp.fill())
 		soname := filepath.Base(base.interpPath)
-		sonamePaths[soname] = append(sonamePaths[soname], mp)
+		if mp.fill() == nil {
+			interpPath = &mp
+			sonamePaths[soname] = append(sonamePaths[soname], newSonamePath(mp, "PT_INTERP"))
+		} else {
+			// near matches are looked for next to where it should be, and in the executable's search path
+			phases := searchdirs
+			dir := multiPath{rootPath: filepath.Dir(base.interpPath), root: base.options.root, mustExist: true}
+			if dir.fill() == nil {
+				phases = append([]searchPhase{{name: "PT_INTERP", dirs: []multiPath{dir}}}, phases...)
+			}
+			base.missingSonames[base.interpPath] = missingSoname{
+				NeededBy:    []string{base.options.elfPath.getRooted()},
+				Interpreter: true,
+				Suggestions: suggestSonames(soname, base.options.root, phases),
+			}
+		}
 	}
 
 	var allSonames []string
 
+	// add a loaded object to sonamePaths, or fill in the DT_SONAME of the PT_INTERP entry
+	recordPath := func(soname string, sp sonamePath) {
+		index := slices.IndexFunc(sonamePaths[soname], func(other sonamePath) bool { return other.Path == sp.Path })
+		if index == -1 {
+			sonamePaths[soname] = append(sonamePaths[soname], sp)
+		} else {
+			sonamePaths[soname][index].Soname = sp.Soname
+		}
+	}
+
+	// queue the dependencies of a loaded object, returning its DT_RPATH chain
+	queueDeps := func(path multiPath, lib *libInfo, parentRpaths []rpathEntry, namespace string) []rpathEntry {
+		origin := getOrigin(path)
+		rpaths := append([]rpathEntry{{owner: path, dirs: lib.rpath}}, parentRpaths...)
+		for _, soname := range lib.sonames {
+			neededBy[soname] = append(neededBy[soname], path.getRooted())
+			if !seenSonames.contains(soname) {
+				sonameQueue.push(sonameWithSearchdirs{
+					soname:     soname,
+					searchdirs: getSearchdirs(path, lib.dynInfo, rpaths, base),
+					origin:     origin,
+					owner:      path,
+					rpaths:     rpaths,
+					namespace:  namespace,
+				})
+				seenSonames.add(soname)
+			}
+		}
+		return rpaths
+	}
+
+	// sonames whose objects define the name of each reference, but not with the required version
+	mismatchedBy := make(map[string][]string)
+
+	// match the definitions of objects in the scope against the references, returning whether any reference was bound to them;
+	// soname is the name the providers were loaded by
+	bindProviders := func(soname string, providers []providedSyms) bool {
+		bound := false
+		for _, provider := range providers {
+			base.scope = appendScope(base.scope, provider)
+			for _, def := range provider.syms {
+				for _, sym := range requiredSyms[def.name] {
+					key := sym.String()
+					if !base.symMatches(sym, def) {
+						// name is defined, but not with the required version
+						base.versionMismatches.add(key)
+						mismatchedBy[key] = append(mismatchedBy[key], soname)
+						continue
+					}
+
+					sl := base.symnameToSonames[key]
+					if !slices.Contains(sl, provider.soname) {
+						base.symnameToSonames[key] = append(sl, provider.soname)
+						// only the first provider is bound to
+						if len(sl) == 0 {
+							base.symbolDefs[key] = def
+							bound = true
+						}
+					}
+				}
+			}
+		}
+		return bound
+	}
+
 	for {
 		element, success := sonameQueue.pop()
 		if !success {
@@ -182,116 +393,436 @@ func (base *baseInfo) getSymMatches(searchdirs []multiPath) error {
 		}
 
 		soname := element.soname
-		if base.options.full {
+		if preloaded.contains(soname) {
+			continue
+		}
+		// preloads are only listed once loaded
+		if base.options.full && element.preload == "" {
 			allSonames = append(allSonames, soname)
 		}
 
 		sonameNeeded := false
-		searchdirs = element.searchdirs
 
-		for path := range getSonamePaths(soname, base.options.root, slices.Values(searchdirs)) {
-			syms, sonames, runpath, archMatch, err := getSyms(path, base)
+		name, dropped := base.neededName(soname, element.owner, element.origin)
+		if dropped != "" {
+			// the loader fails like for a missing soname
+			if element.preload == "" {
+				base.missingSonames[soname] = missingSoname{Reason: dropped}
+				markNeeded(soname)
+			} else {
+				base.ignoredPreloads = append(base.ignoredPreloads, ignoredPreload{Name: soname, Source: element.preload, Reason: dropped})
+			}
+			continue
+		}
+
+		paths := getSonamePaths(name, base.options.root, element.searchdirs, base.hwcaps)
+		namespace := element.namespace
+		if base.androidConfig != nil {
+			var found []sonamePath
+			var denied string
+			found, namespace, denied = base.androidConfig.lookup(name, element.namespace, element.searchdirs, base)
+			if denied != "" {
+				base.deniedSonames[soname] = denied
+			}
+			paths = slices.Values(found)
+		}
+
+		// an interpreter needed by soname is already loaded, and is not searched for
+		if !base.options.allCandidates && interpPath != nil && name == filepath.Base(base.interpPath) {
+			paths = slices.Values([]sonamePath{newSonamePath(*interpPath, "PT_INTERP")})
+		}
+
+		found := false
+		// last reason a candidate for a preload was refused for
+		var preloadRejected string
+		// why the loader failed on a candidate instead of skipping it
+		var fatal string
+		for sp := range paths {
+			// the loader stops at the first compatible candidate, later ones are never looked at
+			if found && !base.options.allCandidates {
+				base.shadowedPaths[soname] = append(base.shadowedPaths[soname], sp)
+				continue
+			}
+
+			path := sp.Path
+			// like __RTLD_SECURE in glibc's open_path
+			if base.secure != "" && element.preload == "LD_PRELOAD" && !isSetuid(path) {
+				preloadRejected = fmt.Sprintf("%s is not set-user-ID, as preloads require in secure mode", path.getRooted())
+				continue
+			}
+
+			lib, rejected, err := getSyms(path, base)
 			if err != nil {
 				return fmt.Errorf("getSymMatches: %w", err)
 			}
 
-			if !archMatch {
+			if rejected.Reason != "" {
+				base.reject(soname, path, rejected)
+				preloadRejected = fmt.Sprintf("%s: %s", path.getRooted(), rejected.Reason)
+				if rejected.Fatal {
+					fatal = preloadRejected
+					break
+				}
 				continue
 			}
+			found = true
+			if element.preload != "" && !preloaded.contains(soname) {
+				if base.options.full {
+					allSonames = append(allSonames, soname)
+				}
+				preloaded.add(soname)
+				seenSonames.add(soname)
+			}
 
-			if base.options.full || slices.Contains(base.sonames, soname) {
-				sonamePaths[soname] = append(sonamePaths[soname], path)
+			sp.Soname = lib.soname
+			base.checkSoname(soname, name, sp)
+			if base.options.full || slices.Contains(base.sonames, soname) || slices.Contains(base.preloads, soname) {
+				recordPath(soname, sp)
 			}
+			base.recordFlags(path, &lib.dynInfo)
 
-			for _, soname := range sonames {
-				if !seenSonames.contains(soname) {
-					sonameQueue.push(sonameWithSearchdirs{
-						soname:     soname,
-						searchdirs: getSearchdirs(runpath, base.options),
-					})
-					seenSonames.add(soname)
-				}
+			rpaths := queueDeps(path, lib, element.rpaths, namespace)
+			if _, ok := base.loaded[soname]; !ok {
+				base.loaded[soname] = &loadedObject{soname: soname, path: path, lib: lib, rpaths: rpaths}
 			}
 
-			for _, sym := range syms {
-				if requiredSymnames.contains(sym) {
-					sl := base.symnameToSonames[sym]
-					if !slices.Contains(sl, soname) {
-						base.symnameToSonames[sym] = append(sl, soname)
-						sonameNeeded = true
+			// filtees are searched with the filter's own rules and precede it in lookups, like in glibc;
+			// the filter's own definitions are only unusable if a DT_FILTER filtee is missing
+			providers := []providedSyms{{soname: soname, syms: lib.syms}}
+			if len(lib.filtees) > 0 {
+				providers = nil
+				// glibc fails to load the filter if a DT_FILTER filtee is missing, so nothing binds to it or its filtees
+				failed := false
+				searchdirs := getSearchdirs(path, lib.dynInfo, rpaths, base)
+				for _, filtee := range lib.filtees {
+					fsp, flib, err := base.loadObject(filtee.soname, searchdirs, path)
+					if err != nil {
+						return fmt.Errorf("getSymMatches: %w", err)
 					}
+					if fsp == nil {
+						if !filtee.auxiliary {
+							failed = true
+							neededBy[filtee.soname] = append(neededBy[filtee.soname], path.getRooted())
+							base.missingSonames[filtee.soname] = missingSoname{
+								Filtee:      true,
+								Reason:      "the filters needing it fail to load, and none of their symbols are bound",
+								Suggestions: suggestSonames(filtee.soname, base.options.root, searchdirs),
+							}
+							// like for a missing soname, whether the filter is needed cannot be told
+							markNeeded(soname)
+						}
+						continue
+					}
+					providers = append(providers, providedSyms{soname: filtee.soname, syms: flib.syms})
+
+					if seenSonames.contains(filtee.soname) {
+						continue
+					}
+					seenSonames.add(filtee.soname)
+					if base.options.full {
+						allSonames = append(allSonames, filtee.soname)
+						sonamePaths[filtee.soname] = append(sonamePaths[filtee.soname], *fsp)
+					}
+					base.recordFlags(fsp.Path, &flib.dynInfo)
+					frpaths := queueDeps(fsp.Path, flib, rpaths, namespace)
+					base.loaded[filtee.soname] = &loadedObject{soname: filtee.soname, path: fsp.Path, lib: flib, rpaths: frpaths}
+				}
+				if failed {
+					providers = nil
+				} else {
+					providers = append(providers, providedSyms{soname: soname, syms: lib.syms})
 				}
 			}
+
+			if bindProviders(soname, providers) {
+				sonameNeeded = true
+			}
 		}
 
-		if sonameNeeded {
-			if index := slices.Index(unneededSonames, soname); index != -1 {
-				unneededSonames = slices.Delete(unneededSonames, index, index+1)
+		switch {
+		case found:
+		// the loader goes on without a preload it cannot load
+		case element.preload != "":
+			reason := "not found"
+			if denied := base.deniedSonames[soname]; denied != "" {
+				reason = denied
+			} else if preloadRejected != "" {
+				reason = preloadRejected
 			}
+			base.ignoredPreloads = append(base.ignoredPreloads, ignoredPreload{Name: soname, Source: element.preload, Reason: reason})
+		case fatal != "":
+			base.missingSonames[soname] = missingSoname{Reason: fatal}
+		case base.deniedSonames[soname] == "":
+			base.missingSonames[soname] = missingSoname{Suggestions: suggestSonames(name, base.options.root, element.searchdirs)}
+		}
+
+		// whether a missing soname is needed cannot be told
+		if sonameNeeded || (!found && element.preload == "") {
+			markNeeded(soname)
 		}
 
-		if !base.options.full && len(base.symnameToSonames) == len(base.syms) {
+		// plugins and -recursive need the whole global scope
+		if !base.options.full && len(base.options.dlopen) == 0 && !base.options.recursive && len(base.symnameToSonames) == len(base.syms) {
 			break
 		}
 	}
 
+	// the interpreter is always loaded, and is appended to the scope unless something needed it by soname;
+	// the vDSO is mapped by the kernel and comes last
+	if interpPath != nil {
+		soname := filepath.Base(base.interpPath)
+		if !seenSonames.contains(soname) {
+			seenSonames.add(soname)
+			lib, rejected, err := getSyms(*interpPath, base)
+			if err != nil {
+				// the loader could not have run the executable, but the rest of the scope is still meaningful
+				rejected = rejection{Reason: err.Error()}
+			}
+			if rejected.Reason != "" {
+				base.reject(soname, *interpPath, rejected)
+			} else {
+				if base.options.full {
+					allSonames = append(allSonames, soname)
+				}
+				sp := newSonamePath(*interpPath, "PT_INTERP")
+				sp.Soname = lib.soname
+				base.checkSoname(soname, soname, sp)
+				recordPath(soname, sp)
+				base.recordFlags(*interpPath, &lib.dynInfo)
+				base.loaded[soname] = &loadedObject{soname: soname, path: *interpPath, lib: lib}
+				bindProviders(soname, []providedSyms{{soname: soname, syms: lib.syms}})
+			}
+		}
+	}
+
+	vdso, err := base.getVDSO()
+	if err != nil {
+		return fmt.Errorf("getSymMatches: %w", err)
+	}
+	// not in the scope at all if the loader does not use it, or there is no table for the architecture
+	if len(vdso.syms) > 0 {
+		bindProviders(vdso.soname, []providedSyms{{soname: vdso.soname, syms: vdso.syms}})
+	}
+
+	// audit libraries are loaded into namespaces of their own, and never provide symbols to the program
+	for _, soname := range base.audit {
+		sp, lib, err := base.loadObject(soname, searchdirs, base.options.elfPath)
+		if err != nil {
+			return fmt.Errorf("getSymMatches: %w", err)
+		}
+		if sp != nil {
+			sonamePaths[soname] = append(sonamePaths[soname], *sp)
+			base.recordFlags(sp.Path, &lib.dynInfo)
+		}
+	}
+
+	for soname, missing := range base.missingSonames {
+		if !missing.Interpreter {
+			missing.NeededBy = uniq(slices.Values(neededBy[soname]))
+			base.missingSonames[soname] = missing
+		}
+	}
+
+	// objects defining the name of a reference only with another version are still needed by it
+	for key, sonames := range mismatchedBy {
+		if len(base.symnameToSonames[key]) == 0 {
+			for _, soname := range sonames {
+				markNeeded(soname)
+			}
+		}
+	}
+
 	base.unneededSonames = unneededSonames
 	base.sonamePaths = sonamePaths
 	if base.options.full {
 		base.sonames = allSonames
+	} else {
+		loadedPreloads := slices.DeleteFunc(slices.Clone(base.preloads), func(soname string) bool { return !preloaded.contains(soname) })
+		base.sonames = uniq(slices.Values(slices.Concat(loadedPreloads, base.sonames)))
 	}
 
 	return nil
 }
 
-func getSyms(path multiPath, base *baseInfo) (syms, sonames []string, runpath []multiPath, archMatch bool, err error) {
-	f, err := elf.Open(path.getReal())
+// rejected is set instead of lib if the loader would refuse to load the object for the base
+func getSyms(path multiPath, base *baseInfo) (lib *libInfo, rejected rejection, err error) {
+	rawF, err := os.Open(path.getReal())
+	if err != nil {
+		return nil, rejection{}, fmt.Errorf("os.Open: %w", err)
+	}
+	defer rawF.Close()
+
+	f, err := elf.NewFile(rawF)
 	if err != nil {
-		return nil, nil, nil, false, fmt.Errorf("elf.Open: %w", err)
+		// glibc fails on files without a valid ELF header or too short to hold one, in open_verify;
+		// only a wrong class or machine makes it go on with the next candidate
+		return nil, rejection{Reason: describeNonELF(rawF, err), Fatal: base.loader.fatalHeaders}, nil
 	}
-	defer f.Close()
 
-	if !(f.Machine == base.machine && f.Class == base.class) {
-		return nil, nil, nil, false, nil
+	abi, err := newABIFingerprint(f, rawF)
+	if err != nil {
+		return nil, rejection{}, fmt.Errorf("getSyms: %w", err)
+	}
+	if reason, fatal := base.abi.incompatibility(abi); reason != "" {
+		return nil, rejection{Reason: reason, Fatal: fatal && base.loader.fatalHeaders}, nil
 	}
+	if f.Type != elf.ET_DYN {
+		return nil, rejection{Reason: fmt.Sprintf("not a shared object (%s)", f.Type)}, nil
+	}
+
+	lib = &libInfo{}
 
 	dynSyms, err := f.DynamicSymbols()
 	if err != nil {
 		if err.Error() == "no symbol section" {
 			// treat as empty
-			return nil, nil, nil, true, nil
+			return lib, rejection{}, nil
 		}
-		return nil, nil, nil, false, fmt.Errorf("getSyms dynsyms: %w", err)
+		return nil, rejection{}, fmt.Errorf("getSyms dynsyms: %w", err)
 	}
 
-	syms = uniq(func(yield func(string) bool) {
+	lib.syms = uniq(func(yield func(symbol) bool) {
 		for _, sym := range dynSyms {
-			if sym.Section != elf.SHN_UNDEF {
-				if !yield(sym.Name) {
+			if base.isEligibleDef(sym) {
+				if !yield(newSymbol(sym)) {
 					return
 				}
 			}
 		}
 	})
 
-	sonames, err = f.DynString(elf.DT_NEEDED)
+	lib.refs = getDynSyms(slices.Values(dynSyms), base.options, true)
+
+	lib.sonames, err = f.DynString(elf.DT_NEEDED)
+	if err != nil {
+		return nil, rejection{}, fmt.Errorf("getSyms DynString: %w", err)
+	}
+	sonames, err := f.DynString(elf.DT_SONAME)
+	if err != nil {
+		return nil, rejection{}, fmt.Errorf("getSyms DT_SONAME: %w", err)
+	}
+	if len(sonames) > 0 {
+		lib.soname = sonames[0]
+	}
+	lib.dynInfo = getDynInfo(f, path, base)
+	if lib.flags1&elf.DF_1_PIE != 0 {
+		return nil, rejection{Reason: "position-independent executable"}, nil
+	}
+
+	lib.filtees, err = readFiltees(f)
 	if err != nil {
-		return nil, nil, nil, false, fmt.Errorf("getSyms DynString: %w", err)
+		return nil, rejection{}, fmt.Errorf("getSyms filtees: %w", err)
+	}
+
+	return lib, rejection{}, nil
+}
+
+// record a loaded object whose DT_SONAME differs from the name it was searched for by;
+// soname is the DT_NEEDED string, which may contain tokens, and name what it expanded or was remapped to
+func (base *baseInfo) checkSoname(soname, name string, sp sonamePath) {
+	if sp.Soname == "" || sp.Soname == soname || sp.Soname == filepath.Base(soname) || sp.Soname == filepath.Base(name) {
+		return
+	}
+	if _, ok := base.sonameMismatches[soname]; !ok {
+		base.sonameMismatches[soname] = sp
+	}
+}
+
+// reason for rejecting a file debug/elf cannot parse
+func describeNonELF(r io.ReaderAt, err error) string {
+	head := make([]byte, 512)
+	n, _ := r.ReadAt(head, 0)
+	head = head[:n]
+
+	switch {
+	case bytes.HasPrefix(head, []byte(elf.ELFMAG)):
+		return "invalid ELF file: " + err.Error()
+	// development symlinks such as libc.so are often linker scripts
+	case bytes.Contains(head, []byte("GNU ld script")) || bytes.Contains(head, []byte("GROUP")) || bytes.Contains(head, []byte("INPUT")):
+		return "linker script"
 	}
-	runpath = getRunPath(f, path)
+	return "not an ELF file"
+}
 
-	return syms, sonames, runpath, true, nil
+func (base *baseInfo) reject(soname string, path multiPath, r rejection) {
+	r.Path = path
+	base.rejected[soname] = append(base.rejected[soname], r)
+}
+
+func (base *baseInfo) recordFlags(path multiPath, info *dynInfo) {
+	if names := info.flagNames(); len(names) > 0 {
+		base.objectFlags[path.getRooted()] = names
+	}
 }
 
-func getSonamePaths(soname, root string, searchdirs iter.Seq[multiPath]) iter.Seq[multiPath] {
+// name the loader searches for when owner needs soname: remapped by libmap.conf, then with tokens expanded;
+// dropped is why the loader fails to load it instead, if it does
+func (base *baseInfo) neededName(soname string, owner multiPath, origin string) (name, dropped string) {
+	name = soname
+	if lm := base.searchdirCache.libmap; lm != nil {
+		if mapped, ok := lm.lookup(owner.getRooted(), soname); ok {
+			name = mapped
+			base.libmapped[soname] = mapped
+		}
+	}
+
+	if !base.loader.expandNeeded {
+		return name, ""
+	}
+	return base.expandDSTReason(name, origin)
+}
+
+// first candidate for a filtee or audit library matching the base architecture; nil if there is none
+func (base *baseInfo) loadObject(soname string, searchdirs []searchPhase, owner multiPath) (*sonamePath, *libInfo, error) {
+	name, dropped := base.neededName(soname, owner, getOrigin(owner))
+	if dropped != "" {
+		return nil, nil, nil
+	}
+
+	for sp := range getSonamePaths(name, base.options.root, searchdirs, base.hwcaps) {
+		lib, rejected, err := getSyms(sp.Path, base)
+		if err != nil {
+			return nil, nil, err
+		}
+		if rejected.Reason == "" {
+			sp.Soname = lib.soname
+			base.checkSoname(soname, name, sp)
+			return &sp, lib, nil
+		}
+		base.reject(soname, sp.Path, rejected)
+		if rejected.Fatal {
+			break
+		}
+	}
+
+	return nil, nil, nil
+}
+
+func getSonamePaths(soname, root string, searchdirs []searchPhase, hwcaps []string) iter.Seq[sonamePath] {
 	if strings.Contains(soname, "/") {
-		return slashSoname(soname, root)
+		return seqMap(slashSoname(soname, root), func(mp multiPath) (sonamePath, bool) {
+			return newSonamePath(mp, "path"), true
+		})
 	}
 
-	paths := mpToRooted(searchdirs, soname)
-	ret := rootedToMultiPath(paths, root, true)
-	ret = uniqExistsPath(ret)
-	return ret
+	return func(yield func(sonamePath) bool) {
+		seen := newSet[string]()
+		for _, phase := range searchdirs {
+			for path := range phase.lookup(soname, root, hwcaps) {
+				realPath := path.getReal()
+				if seen.contains(realPath) {
+					continue
+				}
+				seen.add(realPath)
+				if !pathExists(realPath) {
+					continue
+				}
+				if !yield(newSonamePath(path, phase.name)) {
+					return
+				}
+			}
+		}
+	}
 }
 
 func slashSoname(soname, root string) iter.Seq[multiPath] {
@@ -310,16 +841,12 @@ func slashSoname(soname, root string) iter.Seq[multiPath] {
 	}
 }
 
-func mpToRooted(seq iter.Seq[multiPath], soname string) iter.Seq[string] {
-	return seqMap(seq, func(dir multiPath) (string, bool) { return filepath.Join(dir.getRooted(), soname), true })
-}
-
 func lddSym(options *parseOptions) (*LddResults, error) {
 	options.elfPath.root = "/"
 	options.elfPath.mustExist = true
 	check(options.elfPath.fill())
 
-	if !(options.getFunc || options.getObject || options.getOther) {
+	if !(options.getFunc || options.getObject || options.getTLS || options.getOther) {
 		return nil, errors.New("all symbol types disabled")
 	}
 
@@ -329,73 +856,262 @@ func lddSym(options *parseOptions) (*LddResults, error) {
 		return nil, fmt.Errorf("lddSym root abs: %w", err)
 	}
 
+	// make $ORIGIN of the base relative to the root if it is within it
+	if realPath := options.elfPath.getReal(); options.root != "/" && strings.HasPrefix(realPath, options.root+"/") {
+		options.elfPath = multiPath{
+			realPath:  realPath,
+			root:      options.root,
+			mustExist: true,
+		}
+		check(options.elfPath.fill())
+	}
+
 	base, err := parseBase(options)
 	if err != nil {
 		return nil, fmt.Errorf("lddSym parseBase: %w", err)
 	}
 
-	searchdirs := getSearchdirs(base.runpath, base.options)
+	if base.loader.hwcaps {
+		base.hwcaps, err = getHwcapsSubdirs(options.hwcaps, base.machine, base.class)
+		if err != nil {
+			return nil, fmt.Errorf("lddSym: %w", err)
+		}
+	}
+
+	if base.loader.namespaces {
+		base.androidConfig, err = base.getAndroidConfig()
+		if err != nil {
+			return nil, fmt.Errorf("lddSym: %w", err)
+		}
+	}
+
+	err = base.getSymMatches()
+	if err != nil {
+		return nil, fmt.Errorf("lddSym: %w", err)
+	}
+
+	// before plugins extend the global scope, as startup relocations are done by then
+	if options.recursive {
+		base.resolveLibraryRefs()
+	}
 
-	err = base.getSymMatches(searchdirs)
+	plugins, err := base.openPlugins()
 	if err != nil {
 		return nil, fmt.Errorf("lddSym: %w", err)
 	}
 
-	var undefinedSyms []string
+	var syms, undefinedSyms, versionNotFoundSyms, weakUnresolvedSyms []string
+	symbolBindings := make(map[string]symbolBinding, len(base.symnameToSonames))
 
 	for _, sym := range base.syms {
-		if len(base.symnameToSonames[sym]) == 0 {
-			undefinedSyms = append(undefinedSyms, sym)
+		key := sym.String()
+		if sonames := base.symnameToSonames[key]; len(sonames) != 0 {
+			def := base.symbolDefs[key]
+			if !options.tracksDef(def) {
+				delete(base.symnameToSonames, key)
+				continue
+			}
+			syms = append(syms, key)
+			symbolBindings[key] = symbolBinding{
+				Provider:   sonames[0],
+				Shadowed:   sonames[1:],
+				Attributes: def.names(),
+				Weak:       sym.weak,
+			}
+			continue
+		}
+
+		syms = append(syms, key)
+		switch {
+		case sym.weak:
+			weakUnresolvedSyms = append(weakUnresolvedSyms, key)
+		case base.versionMismatches.contains(key):
+			versionNotFoundSyms = append(versionNotFoundSyms, key)
+		default:
+			undefinedSyms = append(undefinedSyms, key)
 		}
 	}
 
 	ret := &LddResults{
-		Syms:             base.syms,
+		Loader:           base.loader.name,
+		Syms:             syms,
 		Sonames:          base.sonames,
 		SymnameToSonames: base.symnameToSonames,
+		SymbolBindings:   symbolBindings,
 		SonamePaths:      base.sonamePaths,
 		UnneededSonames:  base.unneededSonames,
 		UndefinedSyms:    undefinedSyms,
+
+		VersionNotFoundSyms: versionNotFoundSyms,
+		WeakUnresolvedSyms:  weakUnresolvedSyms,
+		Interposed:          getInterpositions(symbolBindings, base.preloads),
+		IgnoredPreloads:     base.ignoredPreloads,
+		Audit:               base.audit,
+		ObjectFlags:         base.objectFlags,
+		Rejected:            base.rejected,
+		Secure:              base.secure,
+		DeniedSonames:       base.deniedSonames,
+		Plugins:             plugins,
+		Libmap:              base.libmapped,
+		ShadowedPaths:       base.shadowedPaths,
+		MissingSonames:      base.missingSonames,
+		SonameMismatches:    base.sonameMismatches,
+		LibraryRefs:         base.libraryRefs,
 	}
 
 	return ret, nil
 }
 
 func (lddRes *LddResults) noNil() {
-	for _, slicePtr := range []*[]string{&lddRes.Sonames, &lddRes.Syms, &lddRes.UnneededSonames, &lddRes.UndefinedSyms} {
+	for _, slicePtr := range []*[]string{&lddRes.Sonames, &lddRes.Syms, &lddRes.UnneededSonames, &lddRes.UndefinedSyms, &lddRes.VersionNotFoundSyms, &lddRes.WeakUnresolvedSyms, &lddRes.Audit} {
 		if *slicePtr == nil {
 			*slicePtr = make([]string, 0)
 		}
 	}
 
 	if lddRes.SonamePaths == nil {
-		lddRes.SonamePaths = make(map[string][]multiPath)
+		lddRes.SonamePaths = make(map[string][]sonamePath)
 	}
 	if lddRes.SymnameToSonames == nil {
 		lddRes.SymnameToSonames = make(map[string][]string)
 	}
+	if lddRes.SymbolBindings == nil {
+		lddRes.SymbolBindings = make(map[string]symbolBinding)
+	}
+	if lddRes.ObjectFlags == nil {
+		lddRes.ObjectFlags = make(map[string][]string)
+	}
+	if lddRes.Rejected == nil {
+		lddRes.Rejected = make(map[string][]rejection)
+	}
+	if lddRes.Interposed == nil {
+		lddRes.Interposed = make(map[string]interposition)
+	}
+	if lddRes.DeniedSonames == nil {
+		lddRes.DeniedSonames = make(map[string]string)
+	}
+	if lddRes.SonameMismatches == nil {
+		lddRes.SonameMismatches = make(map[string]sonamePath)
+	}
+	if lddRes.MissingSonames == nil {
+		lddRes.MissingSonames = make(map[string]missingSoname)
+	}
+	if lddRes.ShadowedPaths == nil {
+		lddRes.ShadowedPaths = make(map[string][]sonamePath)
+	}
+	if lddRes.Libmap == nil {
+		lddRes.Libmap = make(map[string]string)
+	}
+	if lddRes.LibraryRefs == nil {
+		lddRes.LibraryRefs = make(map[string]refResolution)
+	}
+	for soname, res := range lddRes.LibraryRefs {
+		res.noNil()
+		lddRes.LibraryRefs[soname] = res
+	}
+	if lddRes.Plugins == nil {
+		lddRes.Plugins = make([]pluginResult, 0)
+	}
+	for i := range lddRes.Plugins {
+		lddRes.Plugins[i].noNil()
+	}
+	if lddRes.IgnoredPreloads == nil {
+		lddRes.IgnoredPreloads = make([]ignoredPreload, 0)
+	}
+}
+
+// strong references left unresolved by the executable, plugins or loaded objects; weak ones are not failures
+func (lddRes *LddResults) hasUnresolved() bool {
+	if len(lddRes.UndefinedSyms) > 0 || len(lddRes.VersionNotFoundSyms) > 0 {
+		return true
+	}
+	for _, plugin := range lddRes.Plugins {
+		if plugin.hasUnresolved() {
+			return true
+		}
+	}
+	for _, res := range lddRes.LibraryRefs {
+		if res.hasUnresolved() {
+			return true
+		}
+	}
+	return false
 }
 
-func (lddRes *LddResults) print() {
+func (lddRes *LddResults) print(verbose bool) {
 	for _, sym := range lddRes.Syms {
-		sonames := lddRes.SymnameToSonames[sym]
-		if len(sonames) == 0 {
+		binding, ok := lddRes.SymbolBindings[sym]
+		if !ok {
 			continue
 		}
-		fmt.Printf("%s: %s\n", sym, strings.Join(sonames, ", "))
+		provider := binding.Provider
+		if len(binding.Attributes) > 0 {
+			provider = fmt.Sprintf("%s [%s]", provider, strings.Join(binding.Attributes, ", "))
+		}
+		if binding.Weak {
+			provider += " (weak)"
+		}
+		if len(binding.Shadowed) == 0 {
+			fmt.Printf("%s: %s\n", sym, provider)
+		} else {
+			fmt.Printf("%s: %s (shadows %s)\n", sym, provider, strings.Join(binding.Shadowed, ", "))
+		}
 	}
 
 	if len(lddRes.Syms) > 0 {
 		fmt.Println()
 	}
 
-	for _, soname := range lddRes.Sonames {
-		pathsSl := lddRes.SonamePaths[soname]
-		paths := strings.Join(multiPathToRooted(slices.Values(pathsSl)), ", ")
-		fmt.Printf("%s: %s\n", soname, paths)
+	for _, soname := range slices.Concat(lddRes.Sonames, lddRes.Audit) {
+		if _, ok := lddRes.MissingSonames[soname]; ok && len(lddRes.SonamePaths[soname]) == 0 {
+			fmt.Printf("%s: not found\n", soname)
+			continue
+		}
+		if reason, ok := lddRes.DeniedSonames[soname]; ok && len(lddRes.SonamePaths[soname]) == 0 {
+			fmt.Printf("%s: denied (%s)\n", soname, reason)
+			continue
+		}
+		paths := seqMap(slices.Values(lddRes.SonamePaths[soname]), func(sp sonamePath) (string, bool) { return sp.String(), true })
+		fmt.Printf("%s: %s\n", soname, strings.Join(slices.Collect(paths), ", "))
+	}
+
+	for _, plugin := range lddRes.Plugins {
+		plugin.print()
 	}
 
-	if !(len(lddRes.UnneededSonames) > 0 || len(lddRes.UndefinedSyms) > 0) {
+	if verbose && len(lddRes.ObjectFlags) > 0 {
+		fmt.Println()
+		for _, path := range slices.Sorted(maps.Keys(lddRes.ObjectFlags)) {
+			fmt.Printf("FLAGS %s: %s\n", path, strings.Join(lddRes.ObjectFlags[path], ", "))
+		}
+	}
+
+	if lddRes.Secure != "" {
+		fmt.Println()
+		fmt.Printf("SECURE: %s\n", lddRes.Secure)
+	}
+
+	if len(lddRes.Audit) > 0 {
+		fmt.Println()
+		fmt.Printf("AUDIT: %s\n", strings.Join(lddRes.Audit, ", "))
+	}
+
+	if len(lddRes.Libmap) > 0 {
+		var mapped []string
+		for _, soname := range slices.Sorted(maps.Keys(lddRes.Libmap)) {
+			mapped = append(mapped, fmt.Sprintf("%s -> %s", soname, lddRes.Libmap[soname]))
+		}
+		fmt.Println()
+		fmt.Printf("LIBMAP: %s\n", strings.Join(mapped, ", "))
+	}
+
+	// loaded objects with references left unresolved, including weak ones
+	unresolvedLibs := slices.Collect(seqMap(slices.Values(slices.Sorted(maps.Keys(lddRes.LibraryRefs))), func(soname string) (string, bool) {
+		res := lddRes.LibraryRefs[soname]
+		return soname, res.hasUnresolved() || len(res.WeakUnresolvedSyms) > 0
+	}))
+
+	if !(len(lddRes.UnneededSonames) > 0 || len(unresolvedLibs) > 0 || len(lddRes.UndefinedSyms) > 0 || len(lddRes.VersionNotFoundSyms) > 0 || len(lddRes.WeakUnresolvedSyms) > 0 || len(lddRes.Interposed) > 0 || len(lddRes.IgnoredPreloads) > 0 || len(lddRes.ShadowedPaths) > 0 || len(lddRes.MissingSonames) > 0 || len(lddRes.SonameMismatches) > 0 || len(lddRes.Rejected) > 0 || len(lddRes.DeniedSonames) > 0) {
 		return
 	}
 
@@ -404,27 +1120,147 @@ func (lddRes *LddResults) print() {
 		fmt.Printf("UNNEEDED: %s\n", strings.Join(lddRes.UnneededSonames, ", "))
 	}
 
+	if len(lddRes.MissingSonames) > 0 {
+		var missing []string
+		for _, soname := range slices.Sorted(maps.Keys(lddRes.MissingSonames)) {
+			m := lddRes.MissingSonames[soname]
+			desc := "needed by " + strings.Join(m.NeededBy, ", ")
+			switch {
+			case m.Interpreter:
+				desc = "interpreter of " + strings.Join(m.NeededBy, ", ")
+			case m.Filtee:
+				desc = "filtee of " + strings.Join(m.NeededBy, ", ")
+			}
+			if m.Reason != "" {
+				desc += "; " + m.Reason
+			}
+			if len(m.Suggestions) > 0 {
+				desc += "; similar: " + strings.Join(m.Suggestions, ", ")
+			}
+			missing = append(missing, fmt.Sprintf("%s (%s)", soname, desc))
+		}
+		fmt.Printf("MISSING: %s\n", strings.Join(missing, ", "))
+	}
+
 	if len(lddRes.UndefinedSyms) > 0 {
 		fmt.Printf("UNDEFINED: %s\n", strings.Join(lddRes.UndefinedSyms, ", "))
 	}
+
+	if len(lddRes.VersionNotFoundSyms) > 0 {
+		fmt.Printf("VERSION NOT FOUND: %s\n", strings.Join(lddRes.VersionNotFoundSyms, ", "))
+	}
+
+	if len(lddRes.WeakUnresolvedSyms) > 0 {
+		fmt.Printf("WEAK UNRESOLVED: %s\n", strings.Join(lddRes.WeakUnresolvedSyms, ", "))
+	}
+
+	for _, soname := range unresolvedLibs {
+		res := lddRes.LibraryRefs[soname]
+		if len(res.UndefinedSyms) > 0 {
+			fmt.Printf("UNDEFINED IN %s: %s\n", soname, strings.Join(res.UndefinedSyms, ", "))
+		}
+		if len(res.VersionNotFoundSyms) > 0 {
+			fmt.Printf("VERSION NOT FOUND IN %s: %s\n", soname, strings.Join(res.VersionNotFoundSyms, ", "))
+		}
+		if len(res.WeakUnresolvedSyms) > 0 {
+			fmt.Printf("WEAK UNRESOLVED IN %s: %s\n", soname, strings.Join(res.WeakUnresolvedSyms, ", "))
+		}
+	}
+
+	if len(lddRes.Interposed) > 0 {
+		var interposed []string
+		for _, sym := range lddRes.Syms {
+			if ip, ok := lddRes.Interposed[sym]; ok {
+				interposed = append(interposed, fmt.Sprintf("%s (%s over %s)", sym, ip.Preload, ip.Replaces))
+			}
+		}
+		fmt.Printf("INTERPOSED: %s\n", strings.Join(interposed, ", "))
+	}
+
+	if len(lddRes.IgnoredPreloads) > 0 {
+		var ignored []string
+		for _, ip := range lddRes.IgnoredPreloads {
+			ignored = append(ignored, fmt.Sprintf("%s (from %s; %s)", ip.Name, ip.Source, ip.Reason))
+		}
+		fmt.Printf("IGNORED PRELOAD: %s\n", strings.Join(ignored, ", "))
+	}
+
+	if len(lddRes.SonameMismatches) > 0 {
+		var mismatches []string
+		for _, soname := range slices.Sorted(maps.Keys(lddRes.SonameMismatches)) {
+			sp := lddRes.SonameMismatches[soname]
+			mismatches = append(mismatches, fmt.Sprintf("%s (%s has %s)", soname, sp.Path.getRooted(), sp.Soname))
+		}
+		fmt.Printf("SONAME MISMATCH: %s\n", strings.Join(mismatches, ", "))
+	}
+
+	if len(lddRes.ShadowedPaths) > 0 {
+		var shadowed []string
+		for _, soname := range slices.Sorted(maps.Keys(lddRes.ShadowedPaths)) {
+			for _, sp := range lddRes.ShadowedPaths[soname] {
+				shadowed = append(shadowed, fmt.Sprintf("%s: %s", soname, sp.String()))
+			}
+		}
+		fmt.Printf("SHADOWED: %s\n", strings.Join(shadowed, ", "))
+	}
+
+	if len(lddRes.Rejected) > 0 {
+		var rejected []string
+		for _, soname := range slices.Sorted(maps.Keys(lddRes.Rejected)) {
+			for _, r := range lddRes.Rejected[soname] {
+				reason := r.Reason
+				if r.Fatal {
+					reason += "; fatal"
+				}
+				rejected = append(rejected, fmt.Sprintf("%s: %s (%s)", soname, r.Path.getRooted(), reason))
+			}
+		}
+		fmt.Printf("REJECTED: %s\n", strings.Join(rejected, ", "))
+	}
+
+	if len(lddRes.DeniedSonames) > 0 {
+		var denied []string
+		for _, soname := range slices.Sorted(maps.Keys(lddRes.DeniedSonames)) {
+			denied = append(denied, fmt.Sprintf("%s (%s)", soname, lddRes.DeniedSonames[soname]))
+		}
+		fmt.Printf("DENIED: %s\n", strings.Join(denied, ", "))
+	}
 }
 
 func main() {
 	var options parseOptions
 	var jsonOut bool
+	var verbose bool
+	var failUndefined bool
 	var profFile string
 	flag.StringVar(&options.elfPath.rootPath, "path", "", "path to file")
 	flag.StringVar(&options.root, "root", "/", "directory to consider the root for SONAME resolution")
 	flag.StringVar(&profFile, "profile", "", "path to CPU pprof file (only profiled if set)")
 	flag.StringVar(&options.ldLibraryPath, "ldpath", "", "set LD_LIBRARY_PATH")
+	flag.StringVar(&options.secure, "secure", "auto", `secure-execution mode, as for setuid binaries ("auto", "true" or "false"); "auto" checks the file mode and capabilities`)
+	flag.StringVar(&options.preload, "preload", "", "set LD_PRELOAD (colon or space separated); /etc/ld.so.preload is also read")
+	flag.StringVar(&options.platform, "platform", "", "value of $PLATFORM (AT_PLATFORM); defaults to a common value for the architecture")
+	flag.StringVar(&options.loader, "loader", "", "dynamic linker to emulate ("+loaderNames()+"); detected from PT_INTERP by default")
+	flag.StringVar(&options.hwcaps, "hwcaps", "", `glibc-hwcaps level of the target CPU (e.g. "x86-64-v3"), or "all"`)
+	flag.StringVar(&options.vndkVersion, "vndk-version", "", `value of ro.vndk.version (e.g. "29" or "vndk_lite") selecting /system/etc/ld.config.<version>.txt; versioned configs are not read if unset`)
+	flag.StringVar(&options.vdso, "vdso", "", "path to a vDSO image dumped from a process on the target; a built-in symbol table for the architecture is used by default")
 	flag.BoolVar(&options.getFunc, "funcs", true, "track functions")
 	flag.BoolVar(&options.getObject, "objects", true, "track objects")
 	flag.BoolVar(&options.getOther, "other", false, "track other symbols")
+	flag.BoolVar(&options.getTLS, "tls", false, "track thread-local symbols")
+	flag.BoolVar(&options.getIfunc, "ifunc", true, "track symbols bound to IFUNC definitions")
+	flag.BoolVar(&options.getUnique, "unique", true, "track symbols bound to STB_GNU_UNIQUE definitions")
+	flag.BoolVar(&options.getProtected, "protected", true, "track symbols bound to protected definitions")
+	flag.Var(&options.dlopen, "dlopen", "name[@caller][:global] of an object to dlopen after startup, from the executable or the loaded object with soname caller; with :global, RTLD_GLOBAL makes its objects visible to the following ones; can be repeated")
+	flag.BoolVar(&options.allCandidates, "all-candidates", false, "load every candidate found for a soname instead of stopping at the first compatible one")
+	flag.BoolVar(&options.recursive, "recursive", false, "also look up the references of every loaded object, reporting unresolved ones per soname")
 	flag.BoolVar(&options.full, "full", true, "do not exit out early if all symbols are resolved")
 	flag.BoolVar(&jsonOut, "json", false, "output json")
+	flag.BoolVar(&verbose, "v", false, "also print the DT_FLAGS and DT_FLAGS_1 of each loaded object")
 	flag.BoolVar(&options.std, "std", true, "search standard paths")
-	flag.BoolVar(&options.android, "android", runtime.GOOS == "android", "search Android paths")
+	flag.BoolVar(&options.android, "android", runtime.GOOS == "android", "search Android paths, and emulate the bionic loader if PT_INTERP does not identify one")
 	flag.BoolVar(&options.getWeak, "weak", false, "get weak symbols")
+	flag.BoolVar(&failUndefined, "fail-undefined", false, "exit with status 2 if strong references are undefined or lack the required version, including those of plugins and, with -recursive, of loaded objects")
 	flag.Parse()
 
 	if profFile != "" {
@@ -444,7 +1280,12 @@ func main() {
 		encoded := check1(json.Marshal(lddRes))
 		fmt.Println(string(encoded))
 	} else {
-		lddRes.print()
+		lddRes.print(verbose)
+	}
+
+	if failUndefined && lddRes.hasUnresolved() {
+		pprof.StopCPUProfile()
+		os.Exit(2)
 	}
 }
 
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// soname for which no loadable candidate exists, making the loader fail with "cannot open shared object file"
+type missingSoname struct {
+	// rooted paths of the objects needing it, in lookup order
+	NeededBy []string
+	// the interpreter from PT_INTERP, keyed by its path
+	Interpreter bool `json:",omitempty"`
+	// DT_FILTER filtee of the objects in NeededBy, whose definitions are unusable without it
+	Filtee bool `json:",omitempty"`
+	// rooted paths of files in the searched directories with the same name up to ".so", e.g. libssl.so.3 for libssl.so.1.1
+	Suggestions []string `json:",omitempty"`
+	// why the name is not searched for at all, e.g. a dynamic string token without a value
+	Reason string `json:",omitempty"`
+}
+
+// "libssl" for "libssl.so.1.1", and "ld-linux-x86-64" for "ld-linux-x86-64.so.2"
+func sonameStem(soname string) string {
+	stem, _, _ := strings.Cut(soname, ".so")
+	return stem
+}
+
+func suggestSonames(soname, root string, searchdirs []searchPhase) []string {
+	stem := sonameStem(soname)
+	if stem == "" || strings.Contains(soname, "/") {
+		return nil
+	}
+	similar := func(name string) bool {
+		return name != soname && strings.HasPrefix(name, stem+".so")
+	}
+
+	var ret []string
+	for _, phase := range searchdirs {
+		if phase.cache != nil {
+			for _, name := range slices.Sorted(maps.Keys(phase.cache.entries)) {
+				if similar(name) {
+					for path := range phase.cache.lookup(name, root, nil) {
+						ret = append(ret, path.getRooted())
+					}
+				}
+			}
+			continue
+		}
+
+		for _, dir := range phase.dirs {
+			entries, err := os.ReadDir(dir.getReal())
+			if err != nil {
+				continue
+			}
+			for _, entry := range entries {
+				if similar(entry.Name()) {
+					ret = append(ret, filepath.Join(dir.getRooted(), entry.Name()))
+				}
+			}
+		}
+	}
+
+	return uniq(slices.Values(ret))
+}
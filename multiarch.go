@@ -0,0 +1,113 @@
+package main
+
+import (
+	"debug/elf"
+	"path/filepath"
+)
+
+type archKey struct {
+	machine elf.Machine
+	class   elf.Class
+	data    elf.Data
+}
+
+// Debian multiarch tuples, most likely first
+var multiarchTuples = map[archKey][]string{
+	{elf.EM_X86_64, elf.ELFCLASS64, elf.ELFDATA2LSB}:    {"x86_64-linux-gnu"},
+	{elf.EM_X86_64, elf.ELFCLASS32, elf.ELFDATA2LSB}:    {"x86_64-linux-gnux32"},
+	{elf.EM_386, elf.ELFCLASS32, elf.ELFDATA2LSB}:       {"i386-linux-gnu"},
+	{elf.EM_AARCH64, elf.ELFCLASS64, elf.ELFDATA2LSB}:   {"aarch64-linux-gnu"},
+	{elf.EM_AARCH64, elf.ELFCLASS64, elf.ELFDATA2MSB}:   {"aarch64_be-linux-gnu"},
+	{elf.EM_ARM, elf.ELFCLASS32, elf.ELFDATA2LSB}:       {"arm-linux-gnueabihf", "arm-linux-gnueabi"},
+	{elf.EM_ARM, elf.ELFCLASS32, elf.ELFDATA2MSB}:       {"armeb-linux-gnueabihf", "armeb-linux-gnueabi"},
+	{elf.EM_PPC64, elf.ELFCLASS64, elf.ELFDATA2LSB}:     {"powerpc64le-linux-gnu"},
+	{elf.EM_PPC64, elf.ELFCLASS64, elf.ELFDATA2MSB}:     {"powerpc64-linux-gnu"},
+	{elf.EM_PPC, elf.ELFCLASS32, elf.ELFDATA2MSB}:       {"powerpc-linux-gnu"},
+	{elf.EM_S390, elf.ELFCLASS64, elf.ELFDATA2MSB}:      {"s390x-linux-gnu"},
+	{elf.EM_S390, elf.ELFCLASS32, elf.ELFDATA2MSB}:      {"s390-linux-gnu"},
+	{elf.EM_MIPS, elf.ELFCLASS32, elf.ELFDATA2LSB}:      {"mipsel-linux-gnu", "mips64el-linux-gnuabin32"},
+	{elf.EM_MIPS, elf.ELFCLASS32, elf.ELFDATA2MSB}:      {"mips-linux-gnu", "mips64-linux-gnuabin32"},
+	{elf.EM_MIPS, elf.ELFCLASS64, elf.ELFDATA2LSB}:      {"mips64el-linux-gnuabi64"},
+	{elf.EM_MIPS, elf.ELFCLASS64, elf.ELFDATA2MSB}:      {"mips64-linux-gnuabi64"},
+	{elf.EM_RISCV, elf.ELFCLASS64, elf.ELFDATA2LSB}:     {"riscv64-linux-gnu"},
+	{elf.EM_LOONGARCH, elf.ELFCLASS64, elf.ELFDATA2LSB}: {"loongarch64-linux-gnu"},
+	{elf.EM_SPARCV9, elf.ELFCLASS64, elf.ELFDATA2MSB}:   {"sparc64-linux-gnu"},
+	{elf.EM_ALPHA, elf.ELFCLASS64, elf.ELFDATA2LSB}:     {"alpha-linux-gnu"},
+	{elf.EM_68K, elf.ELFCLASS32, elf.ELFDATA2MSB}:       {"m68k-linux-gnu"},
+	{elf.EM_SH, elf.ELFCLASS32, elf.ELFDATA2LSB}:        {"sh4-linux-gnu"},
+	{elf.EM_PARISC, elf.ELFCLASS32, elf.ELFDATA2MSB}:    {"hppa-linux-gnu"},
+}
+
+// AT_PLATFORM values the kernel reports on the most common CPUs
+var defaultPlatforms = map[elf.Machine]string{
+	elf.EM_X86_64:  "x86_64",
+	elf.EM_386:     "i686",
+	elf.EM_AARCH64: "aarch64",
+	elf.EM_ARM:     "v7l",
+}
+
+// multiarch tuples matching the ABI, most likely first
+func (abi abiFingerprint) multiarchTuples() []string {
+	tuples := multiarchTuples[archKey{abi.machine, abi.class, abi.data}]
+
+	switch {
+	// nothing to pick from, e.g. for ELFCLASS64 EM_ARM objects
+	case len(tuples) < 2:
+		return tuples
+	case abi.machine == elf.EM_ARM && abi.flags&0x400 != 0:
+		return tuples[:1]
+	case abi.machine == elf.EM_ARM && abi.flags&0x200 != 0:
+		return tuples[1:]
+	case abi.machine == elf.EM_MIPS && abi.class == elf.ELFCLASS32:
+		// o32 or n32
+		if abi.flags&0x20 != 0 {
+			return tuples[1:]
+		}
+		return tuples[:1]
+	}
+
+	return tuples
+}
+
+// library directories for the ABI besides multiarch ones, as in glibc's slibdir, followed by lib,
+// which Debian's loader also searches; lib32 is where 64-bit distributions put 32-bit libraries
+func (abi abiFingerprint) libdirs() []string {
+	switch {
+	case abi.machine == elf.EM_X86_64 && abi.class == elf.ELFCLASS32:
+		return []string{"libx32", "lib"}
+	case abi.machine == elf.EM_MIPS && abi.class == elf.ELFCLASS32 && abi.flags&0x20 != 0:
+		return []string{"lib32", "lib"}
+	case abi.machine == elf.EM_MIPS && abi.class == elf.ELFCLASS32:
+		return []string{"lib"}
+	case abi.machine == elf.EM_RISCV && abi.class == elf.ELFCLASS64:
+		floatABI := []string{"lp64", "lp64f", "lp64d", "lp64q"}[(abi.flags&0x6)>>1]
+		return []string{filepath.Join("lib64", floatABI), "lib64", "lib"}
+	case abi.machine == elf.EM_ALPHA:
+		return []string{"lib"}
+	case abi.class == elf.ELFCLASS64:
+		return []string{"lib64", "lib"}
+	}
+
+	return []string{"lib32", "lib"}
+}
+
+// value for $LIB: the multiarch directory if the root has one, the ABI's library directory otherwise
+func getDstLib(root string, abi abiFingerprint) string {
+	for _, tuple := range abi.multiarchTuples() {
+		for _, dir := range []string{"/lib", "/usr/lib"} {
+			if rootedExists(filepath.Join(dir, tuple), root) {
+				return filepath.Join("lib", tuple)
+			}
+		}
+	}
+
+	for _, libdir := range abi.libdirs() {
+		for _, dir := range []string{"/", "/usr"} {
+			if rootedExists(filepath.Join(dir, libdir), root) {
+				return libdir
+			}
+		}
+	}
+
+	return "lib"
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"debug/elf"
+	"slices"
+	"testing"
+)
+
+func TestGetSearchDirCachedStd(t *testing.T) {
+	root := testRoot(t,
+		"/lib/x86_64-linux-gnu/.keep", "/usr/lib/x86_64-linux-gnu/.keep", "/lib64/.keep", "/usr/lib64/.keep",
+		"/lib32/.keep", "/usr/libx32/.keep", "/usr/lib/libpre.so",
+	)
+
+	tests := []struct {
+		name string
+		abi  abiFingerprint
+		want []string
+	}{
+		{
+			name: "x86_64",
+			abi:  abiFingerprint{machine: elf.EM_X86_64, class: elf.ELFCLASS64, data: elf.ELFDATA2LSB},
+			want: []string{"/lib/x86_64-linux-gnu", "/usr/lib/x86_64-linux-gnu", "/lib64", "/usr/lib64", "/lib", "/usr/lib"},
+		},
+		{
+			name: "x32",
+			abi:  abiFingerprint{machine: elf.EM_X86_64, class: elf.ELFCLASS32, data: elf.ELFDATA2LSB},
+			want: []string{"/usr/libx32", "/lib", "/usr/lib"},
+		},
+		{
+			name: "i386",
+			abi:  abiFingerprint{machine: elf.EM_386, class: elf.ELFCLASS32, data: elf.ELFDATA2LSB},
+			want: []string{"/lib32", "/lib", "/usr/lib"},
+		},
+		{
+			name: "RISC-V",
+			abi:  abiFingerprint{machine: elf.EM_RISCV, class: elf.ELFCLASS64, data: elf.ELFDATA2LSB, flags: 0x4},
+			want: []string{"/lib64", "/usr/lib64", "/lib", "/usr/lib"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dirs := slices.Collect(getSearchDirCachedStd(root, tt.abi))
+			if got := rootedDirs(dirs); !slices.Equal(got, tt.want) {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+
+			// libraries directly in /usr/lib are found for every ABI
+			phases := []searchPhase{{kind: phaseDefault, name: "default", dirs: dirs}}
+			found := slices.Collect(getSonamePaths("libpre.so", root, phases, nil))
+			if len(found) != 1 || found[0].Path.getRooted() != "/usr/lib/libpre.so" {
+				t.Errorf("libpre.so found at %v, want /usr/lib/libpre.so", found)
+			}
+		})
+	}
+}
+
+func TestMultiarchTuples(t *testing.T) {
+	tests := []struct {
+		name string
+		abi  abiFingerprint
+		want []string
+	}{
+		{name: "ARM hard-float", abi: abiFingerprint{machine: elf.EM_ARM, class: elf.ELFCLASS32, data: elf.ELFDATA2LSB, flags: 0x400}, want: []string{"arm-linux-gnueabihf"}},
+		{name: "ARM soft-float", abi: abiFingerprint{machine: elf.EM_ARM, class: elf.ELFCLASS32, data: elf.ELFDATA2LSB, flags: 0x200}, want: []string{"arm-linux-gnueabi"}},
+		{name: "ARM without float ABI", abi: abiFingerprint{machine: elf.EM_ARM, class: elf.ELFCLASS32, data: elf.ELFDATA2MSB}, want: []string{"armeb-linux-gnueabihf", "armeb-linux-gnueabi"}},
+		{name: "ARM with ELFCLASS64", abi: abiFingerprint{machine: elf.EM_ARM, class: elf.ELFCLASS64, data: elf.ELFDATA2LSB, flags: 0x400}},
+		{name: "MIPS o32", abi: abiFingerprint{machine: elf.EM_MIPS, class: elf.ELFCLASS32, data: elf.ELFDATA2LSB, flags: 0x1000}, want: []string{"mipsel-linux-gnu"}},
+		{name: "MIPS n32", abi: abiFingerprint{machine: elf.EM_MIPS, class: elf.ELFCLASS32, data: elf.ELFDATA2MSB, flags: 0x20}, want: []string{"mips64-linux-gnuabin32"}},
+		{name: "MIPS without byte order", abi: abiFingerprint{machine: elf.EM_MIPS, class: elf.ELFCLASS32, data: elf.ELFDATANONE, flags: 0x20}},
+		{name: "unknown machine", abi: abiFingerprint{machine: elf.EM_NONE, class: elf.ELFCLASS64, data: elf.ELFDATA2LSB}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.abi.multiarchTuples(); !slices.Equal(got, tt.want) {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -122,6 +122,16 @@ func multiPathToRooted(seq iter.Seq[multiPath]) []string {
 	return slices.Collect(seqMap(seq, func(mp multiPath) (string, bool) { return mp.getRooted(), true }))
 }
 
+// whether the path exists within the root, following symlinks relative to it
+func rootedExists(path, root string) bool {
+	mp := multiPath{
+		rootPath:  path,
+		root:      root,
+		mustExist: true,
+	}
+	return mp.fill() == nil
+}
+
 func pathExists(path string) bool {
 	_, err := os.Stat(path)
 	return err == nil
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"os"
+	"slices"
+	"strings"
+)
+
+// symbol whose binding a preloaded object takes away from the library that would otherwise provide it
+type interposition struct {
+	Preload  string
+	Replaces string
+}
+
+// preload the loader cannot load, which glibc skips with "object ... cannot be preloaded ...: ignored."
+type ignoredPreload struct {
+	Name string
+	// "LD_PRELOAD" or "/etc/ld.so.preload"
+	Source string
+	Reason string
+}
+
+// objects to preload, from -preload followed by /etc/ld.so.preload if the loader reads it,
+// in the order of dl_main in glibc
+func (base *baseInfo) getPreloads() []string {
+	var ret []string
+	for _, name := range splitPreload(base.options.preload) {
+		// in secure mode, only names without slashes are loaded, from the default directories
+		if base.secure != "" {
+			var reason string
+			switch {
+			case !base.loader.secureTrusted:
+				reason = "dropped in secure mode: the loader ignores LD_PRELOAD"
+			case strings.Contains(name, "/"):
+				reason = "dropped in secure mode: contains a slash"
+			}
+			if reason != "" {
+				base.ignoredPreloads = append(base.ignoredPreloads, ignoredPreload{Name: name, Source: "LD_PRELOAD", Reason: reason})
+				continue
+			}
+		}
+		ret = append(ret, name)
+	}
+	base.envPreloads = len(ret)
+
+	if !base.loader.preloadFile {
+		return ret
+	}
+
+	mp := multiPath{
+		rootPath:  "/etc/ld.so.preload",
+		root:      base.options.root,
+		mustExist: true,
+	}
+	if mp.fill() != nil {
+		return ret
+	}
+	data, err := os.ReadFile(mp.getReal())
+	if err != nil {
+		return ret
+	}
+
+	return append(ret, splitPreload(string(data))...)
+}
+
+func splitPreload(s string) []string {
+	return strings.FieldsFunc(s, func(r rune) bool {
+		return r == ':' || r == ' ' || r == '\t' || r == '\n'
+	})
+}
+
+// bindings won by a preloaded object over a library from the normal lookup scope
+func getInterpositions(bindings map[string]symbolBinding, preloads []string) map[string]interposition {
+	ret := make(map[string]interposition)
+	for key, binding := range bindings {
+		if !slices.Contains(preloads, binding.Provider) {
+			continue
+		}
+		for _, soname := range binding.Shadowed {
+			if !slices.Contains(preloads, soname) {
+				ret[key] = interposition{Preload: binding.Provider, Replaces: soname}
+				break
+			}
+		}
+	}
+
+	return ret
+}
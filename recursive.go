@@ -0,0 +1,26 @@
+package main
+
+// look up the references of every object loaded at startup in the global scope, like the relocation
+// processing in glibc's dl_main; the definitions it was parsed with are reused
+func (base *baseInfo) resolveLibraryRefs() {
+	for soname, obj := range base.loaded {
+		base.libraryRefs[soname] = base.bindRefs(obj.lib.refs, obj.lookupScope(base.scope))
+	}
+}
+
+// objects with DT_SYMBOLIC look up their own definitions before the scope
+func (obj *loadedObject) lookupScope(scope []providedSyms) []providedSyms {
+	if !obj.lib.symbolic {
+		return scope
+	}
+	own := []providedSyms{{soname: obj.soname, syms: obj.lib.syms}}
+	for _, provider := range scope {
+		own = appendScope(own, provider)
+	}
+	return own
+}
+
+// strong references without a matching definition
+func (res *refResolution) hasUnresolved() bool {
+	return len(res.UndefinedSyms) > 0 || len(res.VersionNotFoundSyms) > 0
+}
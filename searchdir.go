@@ -6,59 +6,190 @@ import (
 	"os"
 	"path/filepath"
 	"slices"
-	"strings"
 )
 
-var searchDirCached []multiPath
+// soname lookup phases for the dependencies of owner, in the order given by the loader profile;
+// for glibc, the order of _dl_map_object: DT_RPATH of the loading chain (only if owner has no DT_RUNPATH),
+// LD_LIBRARY_PATH, DT_RUNPATH of owner, ld.so.cache, then the default directories
+func getSearchdirs(owner multiPath, info dynInfo, rpaths []rpathEntry, base *baseInfo) []searchPhase {
+	options := base.options
+	profile := base.loader
+	cached := &base.searchdirCache
+	if !cached.filled {
+		base.fillSearchdirCache()
+	}
+
+	var ret []searchPhase
+	for _, kind := range profile.phases {
+		switch kind {
+		case phaseRpath, phaseRpathOwn, phaseRpathExecutable:
+			if info.hasRunpath || len(rpaths) == 0 {
+				continue
+			}
+
+			// rpaths starts with owner and ends with the executable
+			entries := rpaths
+			if kind == phaseRpathOwn {
+				entries = rpaths[:1]
+			} else if kind == phaseRpathExecutable {
+				if len(rpaths) == 1 {
+					continue
+				}
+				entries = rpaths[len(rpaths)-1:]
+			}
+
+			for _, rpath := range entries {
+				if len(rpath.dirs) > 0 {
+					ret = append(ret, searchPhase{
+						kind: kind,
+						name: "rpath of " + rpath.owner.getRooted(),
+						dirs: rpath.dirs,
+					})
+				}
+			}
+		case phaseLdLibraryPath:
+			if len(cached.ldLibraryPath) > 0 {
+				ret = append(ret, searchPhase{kind: kind, name: "LD_LIBRARY_PATH", dirs: cached.ldLibraryPath})
+			}
+		case phaseRunpath:
+			if len(info.runpath) > 0 {
+				ret = append(ret, searchPhase{
+					kind: kind,
+					name: "runpath of " + owner.getRooted(),
+					dirs: info.runpath,
+				})
+			}
+		case phaseCache:
+			if !options.std {
+				continue
+			}
+			if cached.hints != nil {
+				// FreeBSD only drops the default directories from the hints for DF_1_NODEFLIB
+				dirs := cached.hints.dirs
+				if info.nodeflib {
+					dirs = slices.DeleteFunc(slices.Clone(dirs), func(dir multiPath) bool {
+						return slices.ContainsFunc(cached.defaultDirs, func(def multiPath) bool { return def.getReal() == dir.getReal() })
+					})
+				}
+				if len(dirs) > 0 {
+					ret = append(ret, searchPhase{kind: kind, name: filepath.Base(cached.hints.path), dirs: dirs})
+				}
+				continue
+			}
+			if info.nodeflib {
+				continue
+			}
+			if cached.ldCache != nil {
+				ret = append(ret, searchPhase{kind: kind, name: "ld.so.cache", cache: cached.ldCache})
+			} else if len(cached.ldSoConf) > 0 {
+				// approximates the cache ldconfig would generate
+				ret = append(ret, searchPhase{kind: kind, name: "ld.so.conf", dirs: cached.ldSoConf})
+			}
+		case phaseLoaderDir:
+			if !info.nodeflib && len(cached.loaderDir) > 0 {
+				ret = append(ret, searchPhase{kind: kind, name: "loader directory", dirs: cached.loaderDir})
+			}
+		case phaseDefault:
+			if !info.nodeflib {
+				ret = append(ret, searchPhase{kind: kind, name: "default", dirs: cached.defaultDirs})
+			}
+		}
+	}
+
+	return ret
+}
+
+func (base *baseInfo) fillSearchdirCache() {
+	options := base.options
+	profile := base.loader
+	cached := &base.searchdirCache
+	cached.filled = true
 
-func getSearchdirs(runpath []multiPath, options *parseOptions) []multiPath {
-	if searchDirCached != nil {
-		ret := concatSeq(slices.Values(runpath), slices.Values(searchDirCached))
-		ret = uniqExistsPath(ret)
-		return slices.Collect(ret)
+	// LD_LIBRARY_PATH is ignored in secure mode
+	if options.ldLibraryPath != "" && base.secure == "" {
+		dirs := profile.splitPath(options.ldLibraryPath)
+		if profile.expandLdLibraryPath {
+			// $ORIGIN refers to the executable here
+			dirs = base.expandDSTs(dirs, getOrigin(options.elfPath))
+		}
+		cached.ldLibraryPath = slices.Collect(uniqExistsPath(rootedToMultiPath(dirs, options.root, true)))
 	}
 
-	seq := emptySeq[multiPath]
-	if options.ldLibraryPath != "" {
-		seq = concatSeq(seq, rootedToMultiPath(slices.Values(strings.Split(options.ldLibraryPath, ":")), options.root, true))
+	if profile.libmap != nil {
+		cached.libmap = profile.libmap(base)
 	}
 
+	defaultSeq := emptySeq[multiPath]
 	if options.std {
-		seq = concatSeq(seq, getSearchDirCachedStd(options.root))
+		if profile.cache != nil {
+			cached.ldCache = profile.cache(base)
+		}
+		if profile.hints != nil {
+			cached.hints = profile.hints(base)
+		}
+		if profile.ldSoConf {
+			cached.ldSoConf = slices.Collect(uniqExistsPath(getSearchDirCachedLdSoConf(options.root)))
+		}
+		if base.interpPath != "" && slices.Contains(profile.phases, phaseLoaderDir) {
+			dirs := slices.Values([]string{filepath.Dir(base.interpPath)})
+			cached.loaderDir = slices.Collect(uniqExistsPath(rootedToMultiPath(dirs, options.root, true)))
+		}
+		defaultSeq = concatSeq(defaultSeq, profile.defaultDirs(base))
 	}
 
+	// also without -std, which only controls the standard paths of the emulated loader;
+	// duplicates of the bionic defaults are dropped below
 	if options.android {
-		seq = concatSeq(seq, getSearchDirCachedAndroid(options.root))
+		defaultSeq = concatSeq(defaultSeq, getSearchDirCachedAndroid(options.root))
+	}
+
+	cached.defaultDirs = slices.Collect(uniqExistsPath(defaultSeq))
+}
+
+// paths to try for the soname in this phase, in order
+func (phase searchPhase) lookup(soname, root string, hwcaps []string) iter.Seq[multiPath] {
+	if phase.cache != nil {
+		return phase.cache.lookup(soname, root, hwcaps)
 	}
 
-	searchDirCached = slices.Collect(uniqExistsPath(seq))
-	if searchDirCached == nil {
-		searchDirCached = []multiPath{}
+	paths := func(yield func(string) bool) {
+		for _, dir := range phase.dirs {
+			for _, dir := range hwcapsDirs(dir.getRooted(), hwcaps) {
+				if !yield(filepath.Join(dir, soname)) {
+					return
+				}
+			}
+		}
 	}
-	return getSearchdirs(runpath, options)
+
+	return rootedToMultiPath(paths, root, true)
 }
 
-func getSearchDirCachedStd(root string) iter.Seq[multiPath] {
-	// based on glibc and musl defaults
-	// also basically applicable to most non-Linux Unix-based systems
-	paths := []string{
-		"/lib64", "/lib",
-		"/usr/lib64", "/usr/lib",
-		"/usr/local/lib64", "/usr/local/lib",
+// glibc's default directories for the base architecture: the multiarch directories Debian adds,
+// then the architecture's library directories (e.g. lib64 or libx32), then lib
+func getSearchDirCachedStd(root string, abi abiFingerprint) iter.Seq[multiPath] {
+	var paths []string
+	for _, tuple := range abi.multiarchTuples() {
+		paths = append(paths, filepath.Join("/lib", tuple), filepath.Join("/usr/lib", tuple))
+	}
+	for _, libdir := range abi.libdirs() {
+		paths = append(paths, filepath.Join("/", libdir), filepath.Join("/usr", libdir))
 	}
 
-	ret := rootedToMultiPath(slices.Values(paths), root, true)
+	return rootedToMultiPath(slices.Values(paths), root, true)
+}
 
+func getSearchDirCachedLdSoConf(root string) iter.Seq[multiPath] {
 	mp := multiPath{
 		rootPath:  "/etc/ld.so.conf",
 		root:      root,
 		mustExist: true,
 	}
-	if mp.fill() == nil {
-		ret = concatSeq(ret, parseLdSoConfFile(mp, root))
+	if mp.fill() != nil {
+		return emptySeq[multiPath]
 	}
 
-	return ret
+	return parseLdSoConfFile(mp, root)
 }
 
 func getSearchDirCachedAndroid(root string) iter.Seq[multiPath] {
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// why the loader would run the binary in secure-execution mode (AT_SECURE); empty if it would not
+func getSecureReason(option string, path multiPath) (string, error) {
+	switch option {
+	case "true":
+		return "-secure", nil
+	case "false":
+		return "", nil
+	case "auto", "":
+	default:
+		return "", fmt.Errorf("invalid -secure value %q", option)
+	}
+
+	info, err := os.Stat(path.getReal())
+	if err != nil {
+		return "", fmt.Errorf("getSecureReason: %w", err)
+	}
+
+	switch mode := info.Mode(); {
+	case mode&os.ModeSetuid != 0:
+		return "setuid", nil
+	case mode&os.ModeSetgid != 0:
+		return "setgid", nil
+	case hasFileCaps(path.getReal()):
+		return "file capabilities", nil
+	}
+
+	return "", nil
+}
+
+// whether the file has S_ISUID, which LD_PRELOAD entries need in secure mode
+func isSetuid(path multiPath) bool {
+	info, err := os.Stat(path.getReal())
+	return err == nil && info.Mode()&os.ModeSetuid != 0
+}
+
+// whether a path with $ORIGIN substituted is usable in secure mode: only within the default directories for glibc,
+// never for other loaders
+func (base *baseInfo) isTrustedPath(path string) bool {
+	if !base.loader.secureTrusted {
+		return false
+	}
+	if !base.searchdirCache.filled {
+		base.fillSearchdirCache()
+	}
+
+	path = filepath.Clean(path)
+	for _, dir := range base.searchdirCache.defaultDirs {
+		dir := dir.getRooted()
+		if path == dir || strings.HasPrefix(path, dir+"/") {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,13 @@
+//go:build linux
+
+package main
+
+import (
+	"syscall"
+)
+
+// file capabilities also make the kernel set AT_SECURE
+func hasFileCaps(path string) bool {
+	size, err := syscall.Getxattr(path, "security.capability", nil)
+	return err == nil && size > 0
+}
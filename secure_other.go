@@ -0,0 +1,8 @@
+//go:build !linux
+
+package main
+
+// file capabilities are Linux-specific
+func hasFileCaps(path string) bool {
+	return false
+}
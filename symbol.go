@@ -0,0 +1,141 @@
+package main
+
+import (
+	"debug/elf"
+	"slices"
+)
+
+// STB_GNU_UNIQUE, not defined in debug/elf
+const stbGnuUnique = elf.STB_LOOS
+
+// dynamic symbol reference or definition, along with its GNU symbol version
+type symbol struct {
+	name string
+	// empty if unversioned
+	version string
+	// non-default version (sym@VER as opposed to sym@@VER); only set for definitions
+	hidden bool
+	// STB_WEAK reference, left NULL instead of failing if unresolved; only set for references
+	weak bool
+	symbolAttrs
+}
+
+// special kinds of definitions, reported alongside the provider
+type symbolAttrs struct {
+	ifunc     bool
+	tls       bool
+	unique    bool
+	protected bool
+}
+
+func newSymbol(sym elf.Symbol) symbol {
+	return symbol{
+		name:    sym.Name,
+		version: sym.Version,
+		hidden:  sym.HasVersion && sym.VersionIndex.IsHidden(),
+		symbolAttrs: symbolAttrs{
+			ifunc:     elf.ST_TYPE(sym.Info) == elf.STT_GNU_IFUNC,
+			tls:       elf.ST_TYPE(sym.Info) == elf.STT_TLS,
+			unique:    elf.ST_BIND(sym.Info) == stbGnuUnique,
+			protected: elf.ST_VISIBILITY(sym.Other) == elf.STV_PROTECTED,
+		},
+	}
+}
+
+func (attrs symbolAttrs) names() []string {
+	var ret []string
+	for _, attr := range []struct {
+		set  bool
+		name string
+	}{
+		{attrs.ifunc, "ifunc"},
+		{attrs.tls, "tls"},
+		{attrs.unique, "unique"},
+		{attrs.protected, "protected"},
+	} {
+		if attr.set {
+			ret = append(ret, attr.name)
+		}
+	}
+	return ret
+}
+
+// whether a dynamic symbol is a definition the loader can bind to, based on do_lookup_x in glibc's dl-lookup.c
+func (base *baseInfo) isEligibleDef(sym elf.Symbol) bool {
+	if sym.Section == elf.SHN_UNDEF {
+		return false
+	}
+
+	stt := elf.ST_TYPE(sym.Info)
+	// symbols without a value are skipped, apart from TLS ones whose value is an offset
+	if sym.Value == 0 && stt != elf.STT_TLS {
+		return false
+	}
+
+	allowedTypes := []elf.SymType{elf.STT_NOTYPE, elf.STT_OBJECT, elf.STT_FUNC, elf.STT_COMMON, elf.STT_TLS}
+	if base.loader.ifunc {
+		allowedTypes = append(allowedTypes, elf.STT_GNU_IFUNC)
+	}
+	if !slices.Contains(allowedTypes, stt) {
+		return false
+	}
+
+	switch elf.ST_BIND(sym.Info) {
+	case elf.STB_GLOBAL, elf.STB_WEAK, stbGnuUnique:
+	default:
+		return false
+	}
+
+	switch elf.ST_VISIBILITY(sym.Other) {
+	case elf.STV_HIDDEN, elf.STV_INTERNAL:
+		return false
+	}
+
+	return true
+}
+
+// whether references bound to def are tracked according to the -ifunc, -tls, -unique and -protected options
+func (options *parseOptions) tracksDef(def symbol) bool {
+	return !((def.ifunc && !options.getIfunc) ||
+		(def.tls && !options.getTLS) ||
+		(def.unique && !options.getUnique) ||
+		(def.protected && !options.getProtected))
+}
+
+func (sym symbol) String() string {
+	if sym.version == "" {
+		return sym.name
+	}
+	return sym.name + "@" + sym.version
+}
+
+// whether the definition def satisfies the reference sym, based on check_match in glibc's dl-lookup.c
+func (sym symbol) matches(def symbol) bool {
+	if sym.name != def.name {
+		return false
+	}
+
+	if sym.version == "" {
+		// unversioned references bind to the default version only
+		return !def.hidden
+	}
+
+	if sym.version == def.version {
+		return true
+	}
+
+	// unversioned definitions satisfy any versioned reference
+	return def.version == "" && !def.hidden
+}
+
+// sym.matches, subject to the loader's symbol versioning support
+func (base *baseInfo) symMatches(sym, def symbol) bool {
+	switch base.loader.versions {
+	case versionsIgnoreHidden:
+		// musl ignores symbol versions, apart from never binding to non-default ones
+		return sym.name == def.name && !def.hidden
+	case versionsIgnore:
+		return sym.name == def.name
+	}
+	return sym.matches(def)
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"debug/elf"
+	"fmt"
+)
+
+// vDSO exported by the kernel of an architecture, from the version scripts in arch/*/vdso* of Linux
+type vdsoTable struct {
+	soname string
+	syms   []symbol
+}
+
+func newVDSOTable(soname, version string, names ...string) vdsoTable {
+	table := vdsoTable{soname: soname}
+	for _, name := range names {
+		table.syms = append(table.syms, symbol{name: name, version: version})
+	}
+	return table
+}
+
+var vdsoTables = map[elf.Machine]vdsoTable{
+	elf.EM_X86_64: newVDSOTable("linux-vdso.so.1", "LINUX_2.6",
+		"clock_gettime", "__vdso_clock_gettime", "gettimeofday", "__vdso_gettimeofday", "getcpu", "__vdso_getcpu",
+		"time", "__vdso_time", "clock_getres", "__vdso_clock_getres", "__vdso_getrandom", "__vdso_sgx_enter_enclave"),
+	elf.EM_386: func() vdsoTable {
+		table := newVDSOTable("linux-gate.so.1", "LINUX_2.6",
+			"__vdso_clock_gettime", "__vdso_gettimeofday", "__vdso_time", "__vdso_clock_getres", "__vdso_clock_gettime64", "__vdso_getcpu")
+		table.syms = append(table.syms, newVDSOTable("", "LINUX_2.5", "__kernel_vsyscall", "__kernel_sigreturn", "__kernel_rt_sigreturn").syms...)
+		return table
+	}(),
+	elf.EM_AARCH64: newVDSOTable("linux-vdso.so.1", "LINUX_2.6.39",
+		"__kernel_rt_sigreturn", "__kernel_gettimeofday", "__kernel_clock_gettime", "__kernel_clock_getres", "__kernel_getrandom"),
+	elf.EM_ARM: newVDSOTable("linux-vdso.so.1", "LINUX_2.6",
+		"__vdso_gettimeofday", "__vdso_clock_gettime", "__vdso_clock_gettime64", "__vdso_clock_getres"),
+	elf.EM_RISCV: newVDSOTable("linux-vdso.so.1", "LINUX_4.15",
+		"__vdso_rt_sigreturn", "__vdso_gettimeofday", "__vdso_clock_gettime", "__vdso_clock_getres", "__vdso_getcpu",
+		"__vdso_flush_icache", "__vdso_riscv_hwprobe"),
+	elf.EM_PPC64: newVDSOTable("linux-vdso64.so.1", "LINUX_2.6.15",
+		"__kernel_get_syscall_map", "__kernel_gettimeofday", "__kernel_clock_gettime", "__kernel_clock_getres",
+		"__kernel_get_tbfreq", "__kernel_sync_dicache", "__kernel_sigtramp_rt64", "__kernel_getcpu", "__kernel_time"),
+	elf.EM_PPC: newVDSOTable("linux-vdso32.so.1", "LINUX_2.6.15",
+		"__kernel_get_syscall_map", "__kernel_gettimeofday", "__kernel_clock_gettime", "__kernel_clock_gettime64",
+		"__kernel_clock_getres", "__kernel_time", "__kernel_get_tbfreq", "__kernel_sync_dicache",
+		"__kernel_sigtramp32", "__kernel_sigtramp_rt32", "__kernel_getcpu"),
+	elf.EM_S390: newVDSOTable("linux-vdso64.so.1", "LINUX_2.6.29",
+		"__kernel_gettimeofday", "__kernel_clock_gettime", "__kernel_clock_getres", "__kernel_getcpu",
+		"__kernel_restart_syscall", "__kernel_rt_sigreturn", "__kernel_sigreturn"),
+	elf.EM_LOONGARCH: newVDSOTable("linux-vdso.so.1", "LINUX_5.10",
+		"__vdso_getcpu", "__vdso_clock_getres", "__vdso_clock_gettime", "__vdso_gettimeofday", "__vdso_rt_sigreturn", "__vdso_getrandom"),
+	elf.EM_MIPS: newVDSOTable("linux-vdso.so.1", "LINUX_2.6",
+		"__vdso_clock_gettime", "__vdso_gettimeofday", "__vdso_clock_getres"),
+}
+
+// the vDSO the kernel maps into the process, from -vdso if set or the built-in table otherwise;
+// zero if the loader does not use it or the architecture has none
+func (base *baseInfo) getVDSO() (vdsoTable, error) {
+	if !base.loader.vdso {
+		return vdsoTable{}, nil
+	}
+
+	table := vdsoTables[base.machine]
+	if base.options.vdso == "" {
+		return table, nil
+	}
+
+	// an image dumped from a running process, e.g. with gdb's "dump memory" over the [vdso] mapping
+	path := multiPath{
+		rootPath:  base.options.vdso,
+		root:      "/",
+		mustExist: true,
+	}
+	if err := path.fill(); err != nil {
+		return vdsoTable{}, fmt.Errorf("getVDSO: %w", err)
+	}
+	lib, rejected, err := getSyms(path, base)
+	if err != nil {
+		return vdsoTable{}, fmt.Errorf("getVDSO: %w", err)
+	}
+	if rejected.Reason != "" {
+		return vdsoTable{}, fmt.Errorf("getVDSO %s: %s", base.options.vdso, rejected.Reason)
+	}
+	table.syms = lib.syms
+	// the image's own DT_SONAME, falling back to the table's for images without one
+	if lib.soname != "" {
+		table.soname = lib.soname
+	}
+	if table.soname == "" {
+		table.soname = "linux-vdso.so.1"
+	}
+
+	return table, nil
+}